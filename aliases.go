@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// aliasPrefix starts the environment variables defining schedule aliases.
+const aliasPrefix = "GRON_ALIAS_"
+
+// reservedAliases are the schedule formats that aliases cannot replace, in
+// addition to the special schedules.
+var reservedAliases = []string{"@every", "@reboot", "@at", "@oncalendar"}
+
+// loadAliases returns the schedule aliases defined by the configuration file
+// and the GRON_ALIAS_<NAME> variables, which take precedence, by name with
+// the "@" prefix. GRON_ALIAS_BUSINESS_HOURS defines "@business_hours". An
+// alias stands for any schedule expression except another alias.
+func loadAliases(environ []string, fileAliases map[string]string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for name, spec := range fileAliases {
+		aliases[name] = spec
+	}
+	for _, env := range environ {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, aliasPrefix) {
+			continue
+		}
+		aliases[strings.ToLower(strings.TrimPrefix(key, aliasPrefix))] = value
+	}
+
+	resolved := make(map[string]string, len(aliases))
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		alias := "@" + name
+		if !taskNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("alias %s: invalid name, use letters, digits and underscores", alias)
+		}
+		if _, ok := specialSchedules[alias]; ok || slices.Contains(reservedAliases, alias) {
+			return nil, fmt.Errorf("alias %s: replaces a built-in schedule", alias)
+		}
+		spec := strings.Join(strings.Fields(aliases[name]), " ")
+		if _, err := parseSchedule(spec); err != nil {
+			return nil, fmt.Errorf("alias %s: %v", alias, err)
+		}
+		resolved[alias] = spec
+	}
+	return resolved, nil
+}
+
+// expandAlias returns the schedule expression an alias stands for, or spec
+// itself if it is not an alias.
+func expandAlias(spec string, aliases map[string]string) string {
+	if expanded, ok := aliases[strings.TrimSpace(spec)]; ok {
+		return expanded
+	}
+	return spec
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadAliases verifies aliases from the environment and the configuration file
+func TestLoadAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		environ     []string
+		file        map[string]string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "environment",
+			environ:  []string{"GRON_ALIAS_BUSINESS_HOURS=*/15  9-18 * * 1-5", "GRON_OTHER=1"},
+			expected: map[string]string{"@business_hours": "*/15 9-18 * * 1-5"},
+		},
+		{
+			name:     "environment_overrides_file",
+			environ:  []string{"GRON_ALIAS_NIGHTLY=0 2 * * *"},
+			file:     map[string]string{"nightly": "0 3 * * *", "poll": "@every 5m align"},
+			expected: map[string]string{"@nightly": "0 2 * * *", "@poll": "@every 5m align"},
+		},
+		{
+			name:     "special_schedule",
+			environ:  []string{"GRON_ALIAS_EOD=@midnight"},
+			expected: map[string]string{"@eod": "@midnight"},
+		},
+		{
+			name:        "builtin",
+			environ:     []string{"GRON_ALIAS_DAILY=0 1 * * *"},
+			expectedErr: "alias @daily: replaces a built-in schedule",
+		},
+		{
+			name:        "reserved",
+			file:        map[string]string{"every": "0 1 * * *"},
+			expectedErr: "alias @every: replaces a built-in schedule",
+		},
+		{
+			name:        "invalid_name",
+			file:        map[string]string{"Business-Hours": "0 1 * * *"},
+			expectedErr: "alias @Business-Hours: invalid name",
+		},
+		{
+			name:        "invalid_schedule",
+			environ:     []string{"GRON_ALIAS_BAD=0 25 * * *"},
+			expectedErr: "alias @bad: field 2 (hour)",
+		},
+		{
+			name:        "nested",
+			environ:     []string{"GRON_ALIAS_A=0 1 * * *", "GRON_ALIAS_B=@a"},
+			expectedErr: "alias @b: unknown special schedule: @a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aliases, err := loadAliases(tt.environ, tt.file)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("expected error containing '%s', got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(aliases) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, aliases)
+			}
+			for alias, spec := range tt.expected {
+				if aliases[alias] != spec {
+					t.Errorf("expected %s to be '%s', got '%s'", alias, spec, aliases[alias])
+				}
+			}
+		})
+	}
+}
+
+// TestAnnuallyAndMidnight verifies the additional special schedules
+func TestAnnuallyAndMidnight(t *testing.T) {
+	tests := []struct {
+		spec       string
+		normalized string
+	}{
+		{"@annually", "0 0 1 1 *"},
+		{"@midnight", "0 0 * * *"},
+	}
+
+	for _, tt := range tests {
+		task, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.spec, err)
+		}
+		if got := task.normalized(); got != tt.normalized {
+			t.Errorf("expected %s to normalize to '%s', got '%s'", tt.spec, tt.normalized, got)
+		}
+	}
+}
+
+// TestLoadConfigurationAliases verifies tasks of every source can use aliases
+func TestLoadConfigurationAliases(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+aliases:
+  nightly: "30 2 * * *"
+tasks:
+  backup:
+    schedule: "@nightly"
+    command: backup.sh
+`)
+	crontab := filepath.Join(t.TempDir(), "crontab")
+	if err := os.WriteFile(crontab, []byte("# name: check\n@business_hours check.sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	environ := []string{
+		"GRON_ALIAS_BUSINESS_HOURS=*/15 9-18 * * 1-5",
+		"TASK_REPORT=@business_hours report.sh",
+		// Not a companion setting the user of report, as its value is a task
+		"TASK_REPORT_USER=@business_hours users.sh",
+		"TASK_UNKNOWN=@weekdays report.sh",
+	}
+
+	config, err := loadConfiguration(path, []string{crontab}, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 1 || !strings.Contains(config.errors[0].Error(), "unknown special schedule: @weekdays") {
+		t.Errorf("unexpected errors: %v", config.errors)
+	}
+
+	expected := map[string][2]string{
+		"backup":        {"@nightly", "30 2 * * *"},
+		"crontab_check": {"@business_hours", "*/15 9-18 * * 1-5"},
+		"report":        {"@business_hours", "*/15 9-18 * * 1-5"},
+		"report_user":   {"@business_hours", "*/15 9-18 * * 1-5"},
+	}
+	if len(config.tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d", len(expected), len(config.tasks))
+	}
+	for _, task := range config.tasks {
+		want := expected[task.name]
+		if task.spec != want[0] || task.normalized() != want[1] {
+			t.Errorf("task %s: expected %v, got '%s' and '%s'", task.name, want, task.spec, task.normalized())
+		}
+	}
+
+	if _, err := loadConfiguration("", nil, []string{"GRON_ALIAS_HOURLY=0 1 * * *"}); err == nil {
+		t.Error("expected an alias replacing a built-in schedule to be rejected")
+	}
+}
+
+// TestNextCommandAlias verifies aliases can be previewed
+func TestNextCommandAlias(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var stdout, stderr bytes.Buffer
+	environ := []string{"GRON_ALIAS_MORNING=0 9 * * *"}
+
+	if code := nextCommand([]string{"-n", "1", "-tz", "UTC", "@morning"}, environ, now, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected := "Runs at 09:00, every day:\n  Thu 2026-01-01 09:00:00 +00:00 UTC\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
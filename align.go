@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultEveryAnchor is the wall-clock date aligned @every schedules count
+// from unless an anchor is given. It is a Monday, so that weekly intervals
+// start on Mondays.
+var defaultEveryAnchor = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+// minAlignedDelay is the shortest delay an aligned ticker is reset to, as a
+// tick computed just before the ticker is reset may already have passed.
+const minAlignedDelay = time.Millisecond
+
+// everyFieldCount returns the number of fields of an @every schedule at the
+// start of fields: "@every", the duration and the alignment options
+// "align", "offset <duration>" and "anchor <time>".
+func everyFieldCount(fields []string) int {
+	n := 2
+	for n < len(fields) {
+		switch fields[n] {
+		case "align":
+			n++
+		case "offset", "anchor":
+			n += 2
+		default:
+			return n
+		}
+	}
+	return len(fields)
+}
+
+// parseEveryOptions applies the alignment options following the duration of
+// an @every schedule. "align" makes the task fire on multiples of the interval
+// counted from midnight, or from the Monday of the week for intervals of whole
+// days; "offset <duration>" shifts these times and "anchor <time>" counts
+// them from an RFC 3339 time instead. Both imply "align".
+func parseEveryOptions(schedule *CronSchedule, options []string) error {
+	for i := 0; i < len(options); i++ {
+		option := options[i]
+		if option == "align" {
+			schedule.aligned = true
+			continue
+		}
+		if option != "offset" && option != "anchor" {
+			return fmt.Errorf("unknown @every option '%s', expected align, offset or anchor", option)
+		}
+		if i+1 >= len(options) {
+			return fmt.Errorf("missing value for @every option '%s'", option)
+		}
+		i++
+		value := options[i]
+		if option == "offset" {
+			d, err := parseExtendedDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid @every offset: %v", err)
+			}
+			schedule.offset = d
+		} else {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return fmt.Errorf("invalid @every anchor '%s', expected RFC 3339", value)
+			}
+			schedule.anchor = t
+		}
+		schedule.aligned = true
+	}
+	return nil
+}
+
+// alignedDelay returns how long after now the first aligned tick strictly
+// after the given time is, but at least minAlignedDelay, since tickers cannot
+// be reset to a duration of zero or less.
+func (s *CronSchedule) alignedDelay(after, now time.Time) time.Duration {
+	return max(s.nextAlignedTick(after).Sub(now), minAlignedDelay)
+}
+
+// nextAlignedTick returns the first time strictly after t at which an
+// aligned @every schedule fires. The times are wall-clock times in the time
+// zone of the task: "@every 15m align" fires at :00, :15, :30 and :45 of every
+// hour and "@every 1d align offset 6h" at 06:00 every day, also across DST
+// changes.
+func (s *CronSchedule) nextAlignedTick(t time.Time) time.Time {
+	loc := time.Local
+	if s.location != nil {
+		loc = s.location
+	}
+	t = t.In(loc)
+
+	anchor := time.Date(defaultEveryAnchor.Year(), defaultEveryAnchor.Month(), defaultEveryAnchor.Day(), 0, 0, 0, 0, loc)
+	if !s.anchor.IsZero() {
+		anchor = s.anchor.In(loc)
+	}
+	anchor = anchor.Add(s.offset)
+	const day = 24 * time.Hour
+
+	switch {
+	case s.interval%day == 0:
+		// Step in calendar days, keeping the time of day of the anchor.
+		days := int(s.interval / day)
+		elapsed := daysBetween(anchor, t)
+		k := elapsed / days
+		if elapsed < 0 && elapsed%days != 0 {
+			k--
+		}
+		next := anchor.AddDate(0, 0, k*days)
+		for !next.After(t) {
+			next = next.AddDate(0, 0, days)
+		}
+		return next
+
+	case day%s.interval == 0:
+		// Step from midnight of every day, at the phase of the anchor.
+		sinceMidnight := anchor.Sub(time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, loc))
+		phase := sinceMidnight % s.interval
+		for d := 0; ; d++ {
+			midnight := time.Date(t.Year(), t.Month(), t.Day()+d, 0, 0, 0, 0, loc)
+			end := time.Date(t.Year(), t.Month(), t.Day()+d+1, 0, 0, 0, 0, loc)
+			for next := midnight.Add(phase); next.Before(end); next = next.Add(s.interval) {
+				if next.After(t) {
+					return next
+				}
+			}
+		}
+
+	default:
+		elapsed := t.Sub(anchor)
+		k := elapsed / s.interval
+		if elapsed < 0 && elapsed%s.interval != 0 {
+			k--
+		}
+		return anchor.Add((k + 1) * s.interval)
+	}
+}
+
+// daysBetween returns the number of calendar days from the date of a to the
+// date of b.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da) / (24 * time.Hour))
+}
+
+// describeAlignment describes the alignment options of an @every schedule,
+// or returns an empty string if it is not aligned.
+func (s *CronSchedule) describeAlignment() string {
+	if !s.aligned {
+		return ""
+	}
+	var parts []string
+	if !s.anchor.IsZero() {
+		parts = append(parts, "counted from "+s.anchor.Format(time.RFC3339))
+	} else {
+		parts = append(parts, "aligned to the clock")
+	}
+	if s.offset > 0 {
+		parts = append(parts, "offset by "+describeInterval(s.offset))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNextAlignedTick verifies aligned @every schedules fire on wall-clock boundaries
+func TestNextAlignedTick(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		loc      *time.Location
+		from     string
+		expected []string
+	}{
+		{"quarter_hours", "@every 15m align", time.UTC, "2026-01-01T10:07:00Z", []string{"2026-01-01T10:15:00Z", "2026-01-01T10:30:00Z"}},
+		{"on_boundary", "@every 15m align", time.UTC, "2026-01-01T10:15:00Z", []string{"2026-01-01T10:30:00Z"}},
+		{"offset", "@every 15m offset 5m", time.UTC, "2026-01-01T10:07:00Z", []string{"2026-01-01T10:20:00Z", "2026-01-01T10:35:00Z"}},
+		{"end_of_day", "@every 6h align", time.UTC, "2026-01-31T20:00:00Z", []string{"2026-02-01T00:00:00Z", "2026-02-01T06:00:00Z"}},
+		{"hourly_spring_forward", "@every 1h align", berlin, "2026-03-29T01:30:00+01:00", []string{"2026-03-29T03:00:00+02:00", "2026-03-29T04:00:00+02:00"}},
+		{"daily_spring_forward", "@every 1d offset 6h", berlin, "2026-03-28T07:00:00+01:00", []string{"2026-03-29T06:00:00+02:00", "2026-03-30T06:00:00+02:00"}},
+		{"weekly_on_monday", "@every 1w align", time.UTC, "2026-10-14T12:00:00Z", []string{"2026-10-19T00:00:00Z", "2026-10-26T00:00:00Z"}},
+		{"anchor", "@every 6h anchor 2025-01-01T03:00:00Z", time.UTC, "2026-01-01T10:00:00Z", []string{"2026-01-01T15:00:00Z", "2026-01-01T21:00:00Z"}},
+		{"anchor_odd_interval", "@every 7m anchor 2026-01-01T00:00:00Z", time.UTC, "2026-01-01T00:10:00Z", []string{"2026-01-01T00:14:00Z", "2026-01-01T00:21:00Z"}},
+		{"anchor_in_future", "@every 7m anchor 2026-01-01T00:00:00Z", time.UTC, "2025-12-31T23:50:00Z", []string{"2025-12-31T23:53:00Z", "2026-01-01T00:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			task.location = tt.loc
+			from, _ := time.Parse(time.RFC3339, tt.from)
+
+			for i, want := range tt.expected {
+				from = task.next(from)
+				if got := from.In(tt.loc).Format(time.RFC3339); got != want {
+					t.Errorf("tick %d: expected %s, got %s", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestAlignedDelay verifies ticker delays stay positive when the next tick has already passed
+func TestAlignedDelay(t *testing.T) {
+	task, err := parseSchedule("@every 15m align")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.location = time.UTC
+	at := func(s string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339Nano, s)
+		return parsed
+	}
+
+	tests := []struct {
+		name     string
+		after    string
+		now      string
+		expected time.Duration
+	}{
+		{"ahead", "2026-01-01T10:14:59Z", "2026-01-01T10:14:59Z", time.Second},
+		{"on_boundary", "2026-01-01T10:15:00Z", "2026-01-01T10:15:00Z", 15 * time.Minute},
+		{"just_before_boundary", "2026-01-01T10:14:59.999999999Z", "2026-01-01T10:15:00Z", minAlignedDelay},
+		{"passed", "2026-01-01T10:14:59.9Z", "2026-01-01T10:15:00.5Z", minAlignedDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := task.alignedDelay(at(tt.after), at(tt.now)); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseEveryOptions verifies the alignment options of @every schedules
+func TestParseEveryOptions(t *testing.T) {
+	tests := []struct {
+		spec       string
+		normalized string
+		shouldFail bool
+	}{
+		{"@every 15m align", "@every 15m align", false},
+		{"@every 1h offset 5m", "@every 1h align offset 5m", false},
+		{"@every 6h anchor 2025-01-01T03:00:00+01:00", "@every 6h align anchor 2025-01-01T03:00:00+01:00", false},
+		{"@every 1h offset", "", true},
+		{"@every 1h offset -5m", "", true},
+		{"@every 1h anchor yesterday", "", true},
+		{"@every 1h sometimes", "", true},
+		{"@every 0s align", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if tt.shouldFail {
+				if err == nil {
+					t.Errorf("expected error for %s", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.normalized(); got != tt.normalized {
+				t.Errorf("expected %q, got %q", tt.normalized, got)
+			}
+		})
+	}
+
+	spec, command, err := splitTaskDefinition("@every 15m align offset 5m echo hi")
+	if err != nil || spec != "@every 15m align offset 5m" || command != "echo hi" {
+		t.Errorf("unexpected split: %q, %q, %v", spec, command, err)
+	}
+}
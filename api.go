@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAPIAddr is the address the admin API binds to unless configured.
+const defaultAPIAddr = "127.0.0.1:9090"
+
+// taskInfo is the representation of a task returned by the admin API.
+type taskInfo struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// Description describes the schedule in English.
+	Description string     `json:"description"`
+	Command     string     `json:"command"`
+	Paused      bool       `json:"paused"`
+	Running     int        `json:"running"`
+	Skipped     int        `json:"skipped"` // Runs skipped because of calendars.
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *runRecord `json:"last_run,omitempty"`
+}
+
+// describeTask builds the API representation of a task.
+func describeTask(task *CronSchedule, now time.Time) taskInfo {
+	running, lastRun := task.state.snapshot()
+	if lastRun == nil {
+		// Fall back to the persisted history, e.g. after a restart.
+		lastRun = runHistory.last(task.name)
+	}
+	info := taskInfo{
+		Name:        task.name,
+		Schedule:    task.spec,
+		Description: task.describe(),
+		Command:     task.command,
+		Paused:      task.state.isPaused(),
+		Running:     running,
+		Skipped:     task.state.skippedRuns(),
+	}
+	if next := task.next(now); !next.IsZero() {
+		info.NextRun = &next
+	}
+	info.LastRun = lastRun
+	return info
+}
+
+// adminAPI serves the HTTP admin API for a task registry.
+type adminAPI struct {
+	registry *taskRegistry
+	token    string
+}
+
+// adminHandler returns the HTTP handler for the admin API. If token is not
+// empty, every request must carry it as a bearer token.
+func adminHandler(reg *taskRegistry, token string) http.Handler {
+	api := &adminAPI{registry: reg, token: token}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", api.handleList)
+	mux.HandleFunc("GET /tasks/{name}", api.withTask(api.handleGet))
+	mux.HandleFunc("GET /tasks/{name}/history", api.withTask(api.handleHistory))
+	mux.HandleFunc("POST /tasks/{name}/run", api.withTask(api.handleRun))
+	mux.HandleFunc("POST /tasks/{name}/pause", api.withTask(api.handlePause))
+	mux.HandleFunc("POST /tasks/{name}/resume", api.withTask(api.handleResume))
+	return api.authenticate(mux)
+}
+
+// authenticate rejects requests without the configured bearer token.
+func (a *adminAPI) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withTask resolves the {name} path parameter to a task, responding with 404
+// if it does not exist.
+func (a *adminAPI) withTask(handler func(http.ResponseWriter, *http.Request, *CronSchedule)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task := a.registry.get(r.PathValue("name"))
+		if task == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		handler(w, r, task)
+	}
+}
+
+func (a *adminAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	tasks := []taskInfo{}
+	for _, task := range a.registry.list() {
+		tasks = append(tasks, describeTask(task, now))
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (a *adminAPI) handleGet(w http.ResponseWriter, r *http.Request, task *CronSchedule) {
+	writeJSON(w, http.StatusOK, describeTask(task, time.Now()))
+}
+
+func (a *adminAPI) handleHistory(w http.ResponseWriter, r *http.Request, task *CronSchedule) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	history, err := runHistory.list(task.name, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (a *adminAPI) handleRun(w http.ResponseWriter, r *http.Request, task *CronSchedule) {
+	log.Printf("Manual run of task '%s' requested", task.name)
+	go runTask(task, triggerManual)
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "started", "task": task.name})
+}
+
+func (a *adminAPI) handlePause(w http.ResponseWriter, r *http.Request, task *CronSchedule) {
+	task.state.setPaused(true)
+	log.Printf("Task '%s' paused", task.name)
+	writeJSON(w, http.StatusOK, describeTask(task, time.Now()))
+}
+
+func (a *adminAPI) handleResume(w http.ResponseWriter, r *http.Request, task *CronSchedule) {
+	task.state.setPaused(false)
+	log.Printf("Task '%s' resumed", task.name)
+	writeJSON(w, http.StatusOK, describeTask(task, time.Now()))
+}
+
+// startAdminServer starts serving the admin API on addr in the background.
+func startAdminServer(addr string, reg *taskRegistry, token string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: adminHandler(reg, token), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Admin API stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Admin API listening on %s", listener.Addr())
+	return server, nil
+}
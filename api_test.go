@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestRegistry creates a registry with a cron task and an @every task
+func newTestRegistry() *taskRegistry {
+	backup, _ := parseCronSchedule("0 2 * * *")
+	backup.name = "backup"
+	backup.spec = "0 2 * * *"
+	backup.command = "backup_command"
+
+	poll, _ := parseEveryFormat("@every 5m")
+	poll.name = "poll"
+	poll.spec = "@every 5m"
+	poll.command = "poll_command"
+
+	reg := &taskRegistry{}
+	reg.set([]*CronSchedule{poll, backup})
+	return reg
+}
+
+// doRequest performs a request against the admin API handler
+func doRequest(handler http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestAdminListTasks verifies that tasks are listed sorted by name with their next run
+func TestAdminListTasks(t *testing.T) {
+	handler := adminHandler(newTestRegistry(), "")
+
+	rec := doRequest(handler, "GET", "/tasks", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var tasks []taskInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &tasks); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Name != "backup" || tasks[1].Name != "poll" {
+		t.Errorf("expected tasks sorted by name, got %s, %s", tasks[0].Name, tasks[1].Name)
+	}
+	if tasks[0].NextRun == nil || tasks[0].NextRun.Hour() != 2 || tasks[0].NextRun.Minute() != 0 {
+		t.Errorf("expected next run at 02:00, got %v", tasks[0].NextRun)
+	}
+	if tasks[0].Schedule != "0 2 * * *" {
+		t.Errorf("expected schedule '0 2 * * *', got '%s'", tasks[0].Schedule)
+	}
+	if tasks[0].Description != "at 02:00, every day" {
+		t.Errorf("expected description 'at 02:00, every day', got '%s'", tasks[0].Description)
+	}
+}
+
+// TestAdminRunTask verifies manual runs are executed and recorded in history
+func TestAdminRunTask(t *testing.T) {
+	mockRunner := &MockCommandRunner{ReturnOutput: []byte("done")}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+
+	useTestHistory(t)
+	reg := newTestRegistry()
+	handler := adminHandler(reg, "")
+
+	rec := doRequest(handler, "POST", "/tasks/backup/run", "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", rec.Code)
+	}
+
+	// Wait for the run to be recorded
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, lastRun := reg.get("backup").state.snapshot(); lastRun != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec = doRequest(handler, "GET", "/tasks/backup/history", "")
+	var history []runRecord
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 run in history, got %d", len(history))
+	}
+	if history[0].Trigger != triggerManual || history[0].Output != "done" {
+		t.Errorf("unexpected run record: %+v", history[0])
+	}
+}
+
+// TestAdminPauseResume verifies that paused tasks skip scheduled runs
+func TestAdminPauseResume(t *testing.T) {
+	mockRunner := &MockCommandRunner{}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+
+	reg := newTestRegistry()
+	handler := adminHandler(reg, "")
+	task := reg.get("backup")
+
+	if rec := doRequest(handler, "POST", "/tasks/backup/pause", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !task.state.isPaused() {
+		t.Fatal("expected task to be paused")
+	}
+
+	runTask(task, triggerScheduled)
+	if len(mockRunner.Commands) != 0 {
+		t.Errorf("expected paused task not to run, got %d executions", len(mockRunner.Commands))
+	}
+
+	if rec := doRequest(handler, "POST", "/tasks/backup/resume", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	runTask(task, triggerScheduled)
+	if len(mockRunner.Commands) != 1 {
+		t.Errorf("expected resumed task to run once, got %d executions", len(mockRunner.Commands))
+	}
+}
+
+// TestAdminErrors verifies authentication and unknown task handling
+func TestAdminErrors(t *testing.T) {
+	handler := adminHandler(newTestRegistry(), "secret")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		statusCode int
+	}{
+		{"missing_token", "GET", "/tasks", "", http.StatusUnauthorized},
+		{"wrong_token", "GET", "/tasks", "wrong", http.StatusUnauthorized},
+		{"valid_token", "GET", "/tasks", "secret", http.StatusOK},
+		{"unknown_task", "POST", "/tasks/missing/run", "secret", http.StatusNotFound},
+		{"wrong_method", "GET", "/tasks/backup/run", "secret", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(handler, tt.method, tt.path, tt.token)
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Policies for @at tasks whose time already passed when they are loaded.
+const (
+	missedSkip = "skip" // Log and never run the task.
+	missedRun  = "run"  // Run the task once right away.
+)
+
+// completionStore records which @at tasks already fired, so that they do
+// not run again after a restart. Without a path it is kept in memory only.
+type completionStore struct {
+	mu   sync.Mutex
+	path string
+	done map[string]time.Time // Fire time of completed tasks by name.
+}
+
+// completions is the process-wide store of completed @at tasks.
+var completions = &completionStore{}
+
+// newCompletionStore opens the store kept in the file at path. A missing
+// file is an empty store.
+func newCompletionStore(path string) (*completionStore, error) {
+	store := &completionStore{path: path, done: make(map[string]time.Time)}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.done); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return store, nil
+}
+
+// completionStoreFromEnv opens the store configured by GRON_STATE_FILE, or
+// the file "completed.json" in GRON_HISTORY_DIR if only that is set.
+func completionStoreFromEnv() (*completionStore, error) {
+	path := os.Getenv("GRON_STATE_FILE")
+	if path == "" {
+		if dir := os.Getenv("GRON_HISTORY_DIR"); dir != "" {
+			path = filepath.Join(dir, "completed.json")
+		}
+	}
+	return newCompletionStore(path)
+}
+
+// isCompleted reports whether the named task already fired for time at.
+func (c *completionStore) isCompleted(name string, at time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	done, ok := c.done[name]
+	return ok && done.Equal(at)
+}
+
+// markCompleted records that the named task fired for time at.
+func (c *completionStore) markCompleted(name string, at time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done == nil {
+		c.done = make(map[string]time.Time)
+	}
+	c.done[name] = at
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.done, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// persistent reports whether completed tasks survive a restart.
+func (c *completionStore) persistent() bool {
+	return c.path != ""
+}
+
+// parseAtFormat parses the time of an "@at <RFC 3339 time>" schedule.
+func parseAtFormat(value string) (*CronSchedule, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid @at time '%s', expected RFC 3339 such as 2026-11-01T02:00:00+03:00", value)
+	}
+	return &CronSchedule{isAt: true, at: at}, nil
+}
+
+// startAtTimer starts the timer of an @at task. The task fires once at its
+// time and is marked completed by runTask when the run starts, so a run that
+// is skipped, for example because the task is paused, does not count. If the
+// time already passed, the task runs right away if its missed policy is "run"
+// and is skipped otherwise, unless it completed before. The timer runs until
+// it fires or is stopped by the stopTicker method of the task state.
+func startAtTimer(task *CronSchedule) {
+	if completions.isCompleted(task.name, task.at) {
+		log.Printf("Task '%s' already ran for %s", task.name, task.at.Format(time.RFC3339))
+		return
+	}
+
+	trigger := triggerScheduled
+	delay := time.Until(task.at)
+	if delay <= 0 {
+		if !task.runMissed {
+			log.Printf("Skipping task '%s': its time %s has passed", task.name, task.at.Format(time.RFC3339))
+			return
+		}
+		log.Printf("Task '%s' missed its time %s, running it now", task.name, task.at.Format(time.RFC3339))
+		trigger, delay = triggerCatchUp, 0
+	} else {
+		log.Printf("Setting up @at timer for task '%s' at %s", task.name, task.at.Format(time.RFC3339))
+	}
+
+	timer := time.NewTimer(delay)
+	stop := task.state.startTicker()
+	go func() {
+		select {
+		case <-timer.C:
+			runTask(task, trigger)
+		case <-stop:
+			timer.Stop()
+		}
+	}()
+}
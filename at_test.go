@@ -0,0 +1,243 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestCompletions replaces the completion store for the duration of a test
+func useTestCompletions(t *testing.T, path string) {
+	t.Helper()
+	original := completions
+	store, err := newCompletionStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	completions = store
+	t.Cleanup(func() { completions = original })
+}
+
+// waitForRuns waits until the named task has at least n runs in the history
+func waitForRuns(name string, n int) []runRecord {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		runs, _ := runHistory.list(name, 0)
+		if len(runs) >= n || time.Now().After(deadline) {
+			return runs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestParseAtSchedule verifies parsing of @at schedules
+func TestParseAtSchedule(t *testing.T) {
+	tests := []struct {
+		spec       string
+		normalized string
+		describe   string
+		shouldFail bool
+	}{
+		{"@at 2026-11-01T02:00:00+03:00", "@at 2026-11-01T02:00:00+03:00", "once at 2026-11-01T02:00:00+03:00", false},
+		{"@at   2026-11-01T02:00:00Z", "@at 2026-11-01T02:00:00Z", "once at 2026-11-01T02:00:00Z", false},
+		{"@at", "", "", true},
+		{"@at 2026-11-01", "", "", true},
+		{"@at 2026-11-01T02:00:00", "", "", true},
+		{"@at 2026-11-01T02:00:00Z now", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if tt.shouldFail {
+				if err == nil {
+					t.Errorf("expected an error for '%s'", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.normalized(); got != tt.normalized {
+				t.Errorf("expected normalized '%s', got '%s'", tt.normalized, got)
+			}
+			if got := task.describe(); got != tt.describe {
+				t.Errorf("expected description '%s', got '%s'", tt.describe, got)
+			}
+		})
+	}
+}
+
+// TestAtNext verifies @at schedules fire once and not after completion
+func TestAtNext(t *testing.T) {
+	useTestCompletions(t, "")
+	task, err := parseSchedule("@at 2026-11-01T02:00:00+03:00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.name = "release"
+	at := task.at
+
+	if got := task.next(at.Add(-time.Hour)); !got.Equal(at) {
+		t.Errorf("expected %v, got %v", at, got)
+	}
+	if got := task.next(at); !got.IsZero() {
+		t.Errorf("expected no run after the time, got %v", got)
+	}
+	if got := task.upcoming(at.Add(-time.Hour), 3); len(got) != 1 {
+		t.Errorf("expected a single upcoming run, got %v", got)
+	}
+
+	completions.markCompleted("release", at)
+	if got := task.next(at.Add(-time.Hour)); !got.IsZero() {
+		t.Errorf("expected no run after completion, got %v", got)
+	}
+}
+
+// TestCompletionStorePersistence verifies completed tasks are remembered across restarts
+func TestCompletionStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	at := time.Date(2026, 11, 1, 2, 0, 0, 0, time.FixedZone("", 3*3600))
+
+	store, err := newCompletionStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.isCompleted("release", at) {
+		t.Fatal("expected a new store to be empty")
+	}
+	if err := store.markCompleted("release", at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := newCompletionStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reopened.isCompleted("release", at) {
+		t.Error("expected the completion to survive reopening the store")
+	}
+	if reopened.isCompleted("release", at.Add(time.Hour)) {
+		t.Error("expected a rescheduled task not to be completed")
+	}
+	if reopened.isCompleted("other", at) {
+		t.Error("expected other tasks not to be completed")
+	}
+}
+
+// TestStartAtTimer verifies when @at tasks run
+func TestStartAtTimer(t *testing.T) {
+	originalRunner := defaultCommandRunner
+	setCommandRunner(&MockCommandRunner{})
+	defer setCommandRunner(originalRunner)
+
+	tests := []struct {
+		name      string
+		offset    time.Duration
+		missed    string
+		completed bool
+		paused    bool
+		trigger   string
+	}{
+		{"future", 50 * time.Millisecond, "", false, false, triggerScheduled},
+		{"past_skipped", -time.Hour, missedSkip, false, false, ""},
+		{"past_run", -time.Hour, missedRun, false, false, triggerCatchUp},
+		{"completed", -time.Hour, missedRun, true, false, ""},
+		{"paused", 50 * time.Millisecond, "", false, true, ""},
+		{"paused_past_run", -time.Hour, missedRun, false, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestHistory(t)
+			path := filepath.Join(t.TempDir(), "state.json")
+			useTestCompletions(t, path)
+
+			at := time.Now().Add(tt.offset)
+			// Start delay and jitter would hold the run back past the waits below.
+			task := &CronSchedule{name: "once", command: "echo once", isAt: true, at: at, runMissed: tt.missed == missedRun,
+				startDelay: time.Hour, jitter: time.Hour}
+			task.state.setPaused(tt.paused)
+			if tt.completed {
+				completions.markCompleted("once", at)
+			}
+
+			startAtTimer(task)
+			defer task.state.stopTicker()
+
+			if tt.trigger == "" {
+				time.Sleep(150 * time.Millisecond)
+				if runs, _ := runHistory.list("once", 0); len(runs) != 0 {
+					t.Errorf("expected no runs, got %d", len(runs))
+				}
+				if reopened, _ := newCompletionStore(path); reopened.isCompleted("once", at) != tt.completed {
+					t.Errorf("expected completed to stay %v", tt.completed)
+				}
+				return
+			}
+			runs := waitForRuns("once", 1)
+			if len(runs) != 1 || runs[0].Trigger != tt.trigger {
+				t.Fatalf("expected one %s run, got %+v", tt.trigger, runs)
+			}
+			reopened, _ := newCompletionStore(path)
+			if !reopened.isCompleted("once", at) {
+				t.Error("expected the run to be recorded as completed")
+			}
+		})
+	}
+}
+
+// TestLoadConfigurationMissed verifies the missed option of @at tasks
+func TestLoadConfigurationMissed(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+defaults:
+  missed: run
+tasks:
+  release:
+    schedule: "@at 2026-11-01T02:00:00+03:00"
+    command: echo release
+  cleanup:
+    schedule: "@at 2026-11-02T02:00:00+03:00"
+    command: echo cleanup
+    missed: skip
+  hourly:
+    schedule: "@hourly"
+    command: echo hourly
+  bad:
+    schedule: "@hourly"
+    command: echo bad
+    missed: run
+  invalid:
+    schedule: "@at 2026-11-01T02:00:00Z"
+    command: echo invalid
+    missed: later
+`)
+	config, err := loadConfiguration(path, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tasks := make(map[string]*CronSchedule)
+	for _, task := range config.tasks {
+		tasks[task.name] = task
+	}
+	if release := tasks["release"]; release == nil || !release.runMissed {
+		t.Errorf("expected release to run when missed: %+v", release)
+	}
+	if cleanup := tasks["cleanup"]; cleanup == nil || cleanup.runMissed {
+		t.Errorf("expected cleanup to be skipped when missed: %+v", cleanup)
+	}
+	if hourly := tasks["hourly"]; hourly == nil || hourly.runMissed {
+		t.Errorf("expected the default not to apply to hourly: %+v", hourly)
+	}
+
+	var messages []string
+	for _, err := range config.errors {
+		messages = append(messages, err.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	if len(messages) != 2 || !strings.Contains(joined, "tasks.bad: missed only applies to @at tasks") || !strings.Contains(joined, "tasks.invalid: invalid missed 'later'") {
+		t.Errorf("unexpected errors: %v", messages)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// yearField holds the range of the optional sixth field of cron expressions,
+// the year, which Quartz writes as its seventh field after the seconds.
+var yearField = CronField{1970, 2199}
+
+// yearFieldPattern matches fields that are read as a year rather than as the
+// start of a command: "*" or four-digit years, ranges, steps and lists.
+var yearFieldPattern = regexp.MustCompile(`^(\*|\d{4}(-\d{4})?)(/\d+)?(,(\*|\d{4}(-\d{4})?)(/\d+)?)*$`)
+
+// dateLayout is the layout of dates in bounds and calendar files.
+const dateLayout = "2006-01-02"
+
+// parseYearField parses the year field of a cron expression. A "*" field
+// returns nil, so that the schedule matches any year.
+func parseYearField(field string) ([]int, error) {
+	if field == "*" {
+		return nil, nil
+	}
+	years, err := parseField(field, yearField)
+	if err != nil {
+		return nil, fmt.Errorf("field 6 (year) '%s': %v", field, err)
+	}
+	return years, nil
+}
+
+// parseBound parses a not_before or not_after bound: an RFC 3339 time or a
+// date in the time zone of the task. A date given as the upper bound includes
+// the whole day.
+func parseBound(value string, loc *time.Location, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	t, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a date like 2026-11-01")
+	}
+	if upper {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// activeAt reports whether t falls within the not_before and not_after
+// bounds of the task.
+func (s *CronSchedule) activeAt(t time.Time) bool {
+	return (s.notBefore.IsZero() || !t.Before(s.notBefore)) && !s.expiredAt(t)
+}
+
+// expiredAt reports whether the task will never run again after t, because
+// t is past its not_after bound or its last year.
+func (s *CronSchedule) expiredAt(t time.Time) bool {
+	if !s.notAfter.IsZero() && t.After(s.notAfter) {
+		return true
+	}
+	if len(s.years) > 0 {
+		if s.location != nil {
+			t = t.In(s.location)
+		}
+		return t.Year() > s.years[len(s.years)-1]
+	}
+	return false
+}
+
+// checkExpired reports whether the task expired at t, and logs it the first
+// time it does.
+func (s *CronSchedule) checkExpired(t time.Time) bool {
+	if !s.expiredAt(t) {
+		return false
+	}
+	if s.state.markExpired() {
+		log.Printf("Task '%s' has expired and will not run again", s.name)
+	}
+	return true
+}
+
+// describeYears describes the years of a schedule, or returns an empty
+// string if it runs every year.
+func describeYears(years []int) string {
+	if len(years) == 0 {
+		return ""
+	}
+	return "in " + joinAnd(describeValues(years, strconv.Itoa))
+}
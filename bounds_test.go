@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestYearField verifies cron expressions with the optional year field
+func TestYearField(t *testing.T) {
+	tests := []struct {
+		spec       string
+		normalized string
+		describe   string
+		shouldFail bool
+	}{
+		{"0 9 * 11,12 * 2026", "0 9 * 11,12 * 2026", "at 09:00, in November and December, in 2026", false},
+		{"0 9 1 1 * 2026-2028", "0 9 1 1 * 2026-2028", "at 09:00, on day 1 of the month, in January, in 2026 through 2028", false},
+		{"0 9 * * * *", "0 9 * * *", "at 09:00, every day", false},
+		{"0 9 * * * 1969", "", "", true},
+		{"0 9 * * * 2026 2027", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if tt.shouldFail {
+				if err == nil {
+					t.Errorf("expected an error for '%s'", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.normalized(); got != tt.normalized {
+				t.Errorf("expected normalized '%s', got '%s'", tt.normalized, got)
+			}
+			if got := task.describe(); got != tt.describe {
+				t.Errorf("expected description '%s', got '%s'", tt.describe, got)
+			}
+		})
+	}
+}
+
+// TestYearFieldSchedule verifies runs are limited to the years of the schedule
+func TestYearFieldSchedule(t *testing.T) {
+	task, err := parseSchedule("0 0 1 * * 2027,2029")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.location = time.UTC
+
+	if task.shouldRun(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected no run in 2026")
+	}
+	if !task.shouldRun(time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected a run in 2027")
+	}
+
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	expected := []string{"2027-01-01T00:00:00Z", "2027-02-01T00:00:00Z"}
+	for i, want := range expected {
+		from = task.next(from)
+		if got := from.Format(time.RFC3339); got != want {
+			t.Errorf("run %d: expected %s, got %s", i, want, got)
+		}
+	}
+	if got := task.next(time.Date(2027, 12, 1, 0, 0, 0, 0, time.UTC)); got.Format(time.RFC3339) != "2029-01-01T00:00:00Z" {
+		t.Errorf("expected the next run in 2029, got %v", got)
+	}
+	if got := task.next(time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("expected no run after the last year, got %v", got)
+	}
+
+	far, _ := parseSchedule("0 0 1 1 * 2150")
+	if got := far.next(from); got.Year() != 2150 {
+		t.Errorf("expected a run in 2150, got %v", got)
+	}
+}
+
+// TestSplitTaskDefinitionYear verifies the year field is not taken for the command
+func TestSplitTaskDefinitionYear(t *testing.T) {
+	tests := []struct {
+		definition string
+		spec       string
+		command    string
+	}{
+		{"0 9 * * * 2026 echo hi", "0 9 * * * 2026", "echo hi"},
+		{"0 9 * * * 2026-2027,2030 echo hi", "0 9 * * * 2026-2027,2030", "echo hi"},
+		{"0 9 * * * echo 2026", "0 9 * * *", "echo 2026"},
+		{"0 9 * * * /bin/report 2026", "0 9 * * *", "/bin/report 2026"},
+	}
+
+	for _, tt := range tests {
+		spec, command, err := splitTaskDefinition(tt.definition)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", tt.definition, err)
+		}
+		if spec != tt.spec || command != tt.command {
+			t.Errorf("%s: expected '%s' and '%s', got '%s' and '%s'", tt.definition, tt.spec, tt.command, spec, command)
+		}
+	}
+}
+
+// TestParseBound verifies dates and times of not_before and not_after
+func TestParseBound(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		value    string
+		upper    bool
+		expected string
+	}{
+		{"2026-11-01", false, "2026-11-01T00:00:00+01:00"},
+		{"2027-01-31", true, "2027-01-31T23:59:59.999999999+01:00"},
+		{"2026-11-01T08:00:00Z", false, "2026-11-01T08:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBound(tt.value, berlin, tt.upper)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", tt.value, err)
+		}
+		if got.Format(time.RFC3339Nano) != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.value, tt.expected, got.Format(time.RFC3339Nano))
+		}
+	}
+	if _, err := parseBound("November", berlin, false); err == nil {
+		t.Error("expected an error for an invalid bound")
+	}
+}
+
+// TestBoundedSchedule verifies runs are limited to the bounds of the task
+func TestBoundedSchedule(t *testing.T) {
+	task, err := parseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.location = time.UTC
+	task.notBefore, _ = parseBound("2026-11-01", time.UTC, false)
+	task.notAfter, _ = parseBound("2026-11-02", time.UTC, true)
+
+	if task.shouldRun(time.Date(2026, 10, 31, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected no run before not_before")
+	}
+	if !task.shouldRun(time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected a run on the last day")
+	}
+	if task.shouldRun(time.Date(2026, 11, 3, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected no run after not_after")
+	}
+
+	times := task.upcoming(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), 5)
+	if len(times) != 2 || times[0].Format(time.RFC3339) != "2026-11-01T09:00:00Z" || times[1].Format(time.RFC3339) != "2026-11-02T09:00:00Z" {
+		t.Errorf("unexpected upcoming runs: %v", times)
+	}
+
+	every, _ := parseSchedule("@every 12h")
+	every.notBefore = time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC)
+	every.notAfter = time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	times = every.upcoming(time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), 5)
+	if len(times) != 2 || times[0].Format(time.RFC3339) != "2026-11-01T12:00:00Z" || times[1].Format(time.RFC3339) != "2026-11-02T00:00:00Z" {
+		t.Errorf("unexpected upcoming @every runs: %v", times)
+	}
+}
+
+// TestCheckExpired verifies expired tasks are logged once and not run
+func TestCheckExpired(t *testing.T) {
+	useTestHistory(t)
+	originalRunner := defaultCommandRunner
+	setCommandRunner(&MockCommandRunner{})
+	defer setCommandRunner(originalRunner)
+
+	var buf bytes.Buffer
+	originalOutput := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(originalOutput)
+
+	task, _ := parseSchedule("* * * * *")
+	task.name = "campaign"
+	task.command = "echo campaign"
+	task.notAfter = time.Now().Add(-time.Hour)
+
+	now := time.Now()
+	if !task.checkExpired(now) || !task.checkExpired(now) {
+		t.Error("expected the task to be expired")
+	}
+	if n := strings.Count(buf.String(), "Task 'campaign' has expired"); n != 1 {
+		t.Errorf("expected the expiry to be logged once, got %d times: %s", n, buf.String())
+	}
+
+	runTask(task, triggerScheduled)
+	if runs, _ := runHistory.list("campaign", 0); len(runs) != 0 {
+		t.Errorf("expected no scheduled run, got %d", len(runs))
+	}
+	runTask(task, triggerManual)
+	if runs, _ := runHistory.list("campaign", 0); len(runs) != 1 {
+		t.Errorf("expected a manual run, got %d", len(runs))
+	}
+}
+
+// TestLoadConfigurationBounds verifies the not_before and not_after options
+func TestLoadConfigurationBounds(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+tasks:
+  campaign:
+    schedule: "0 9 * * *"
+    command: echo campaign
+    timezone: Europe/Berlin
+    not_before: "2026-11-01"
+    not_after: "2027-01-31"
+  reversed:
+    schedule: "0 9 * * *"
+    command: echo reversed
+    not_before: "2027-01-31"
+    not_after: "2026-11-01"
+  invalid:
+    schedule: "0 9 * * *"
+    command: echo invalid
+    not_after: "soon"
+`)
+	environ := []string{"TASK_PROMO=0 9 * * * 2026 promo.sh", "TASK_PROMO_NOT_BEFORE=2026-12-01T00:00:00Z"}
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tasks := make(map[string]*CronSchedule)
+	for _, task := range config.tasks {
+		tasks[task.name] = task
+	}
+	campaign := tasks["campaign"]
+	if campaign == nil || campaign.notBefore.Format(time.RFC3339) != "2026-11-01T00:00:00+01:00" || campaign.notAfter.Format(time.RFC3339) != "2027-01-31T23:59:59+01:00" {
+		t.Errorf("unexpected campaign task: %+v", campaign)
+	}
+	promo := tasks["promo"]
+	if promo == nil || promo.notBefore.Format(time.RFC3339) != "2026-12-01T00:00:00Z" || len(promo.years) != 1 {
+		t.Errorf("unexpected promo task: %+v", promo)
+	}
+
+	var messages []string
+	for _, err := range config.errors {
+		messages = append(messages, err.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	if len(messages) != 2 || !strings.Contains(joined, "tasks.reversed: not_after 2026-11-01 is before not_before 2027-01-31") || !strings.Contains(joined, "tasks.invalid: invalid not_after 'soon'") {
+		t.Errorf("unexpected errors: %v", messages)
+	}
+}
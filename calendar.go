@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarPrefix starts the environment variables naming calendar files.
+const calendarPrefix = "GRON_CALENDAR_"
+
+// maxCalendarDays bounds how many days next skips because of calendars.
+const maxCalendarDays = 5 * 366
+
+// calendar is a named set of days, such as public holidays or maintenance
+// windows, that tasks skip or are limited to.
+type calendar struct {
+	name   string
+	path   string          // File the calendar was read from.
+	dates  map[string]bool // Days listed once, as "2006-01-02".
+	yearly []yearlyEvent   // Days listed every year.
+}
+
+// yearlyEvent is an iCalendar event that repeats every year.
+type yearlyEvent struct {
+	month     time.Month
+	day       int
+	days      int // Number of days the event lasts.
+	from, end int // First and last year; end is 0 for no end.
+}
+
+// loadCalendars loads the calendars named by the configuration file and the
+// GRON_CALENDAR_<NAME> variables, which take precedence, by name.
+// GRON_CALENDAR_HOLIDAYS=/etc/gron/holidays.ics defines "holidays".
+func loadCalendars(environ []string, filePaths map[string]string) (map[string]*calendar, error) {
+	paths := make(map[string]string)
+	for name, path := range filePaths {
+		paths[name] = path
+	}
+	for _, env := range environ {
+		key, value, ok := strings.Cut(env, "=")
+		if ok && strings.HasPrefix(key, calendarPrefix) {
+			paths[strings.ToLower(strings.TrimPrefix(key, calendarPrefix))] = value
+		}
+	}
+
+	calendars := make(map[string]*calendar, len(paths))
+	for name, path := range paths {
+		if !taskNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("calendar '%s': invalid name, use letters, digits and underscores", name)
+		}
+		cal, err := readCalendar(name, path)
+		if err != nil {
+			return nil, fmt.Errorf("calendar '%s': %v", name, err)
+		}
+		calendars[name] = cal
+	}
+	return calendars, nil
+}
+
+// readCalendar reads a calendar file: an iCalendar file if its name ends in
+// ".ics", a list of dates otherwise.
+func readCalendar(name, path string) (*calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cal := &calendar{name: name, path: path, dates: make(map[string]bool)}
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		err = cal.parseICS(string(data))
+	} else {
+		err = cal.parseDateList(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cal, nil
+}
+
+// parseDateList reads a list of dates, one per line: "2026-12-25" or an
+// inclusive range "2026-12-24..2026-12-31". Text after "#" is a comment.
+func (c *calendar) parseDateList(content string) error {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(line, "..")
+		start, err := time.Parse(dateLayout, strings.TrimSpace(first))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid date '%s', expected 2006-01-02", n, line)
+		}
+		end := start
+		if isRange {
+			if end, err = time.Parse(dateLayout, strings.TrimSpace(last)); err != nil || end.Before(start) {
+				return fmt.Errorf("line %d: invalid range '%s'", n, line)
+			}
+		}
+		c.addDays(start, end.AddDate(0, 0, 1))
+	}
+	return scanner.Err()
+}
+
+// parseICS reads the events of an iCalendar file. Every day an event touches
+// is part of the calendar; events repeating yearly are supported, other
+// recurrence rules are rejected.
+func (c *calendar) parseICS(content string) error {
+	// Unfold continuation lines, which start with a space or a tab.
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	inEvent := false
+	var start, end, rule string
+	for _, line := range lines {
+		property, value, _ := strings.Cut(line, ":")
+		property, _, _ = strings.Cut(property, ";")
+		switch strings.ToUpper(property) {
+		case "BEGIN":
+			if value == "VEVENT" {
+				inEvent, start, end, rule = true, "", "", ""
+			}
+		case "DTSTART":
+			start = value
+		case "DTEND":
+			end = value
+		case "RRULE":
+			rule = value
+		case "END":
+			if value == "VEVENT" && inEvent {
+				inEvent = false
+				if err := c.addEvent(start, end, rule); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addEvent adds the days of an iCalendar event. The end of all-day events is
+// exclusive, that of timed events is the day they end on, unless they end at
+// midnight.
+func (c *calendar) addEvent(startValue, endValue, rule string) error {
+	start, _, err := parseICSDate(startValue)
+	if err != nil {
+		return fmt.Errorf("invalid DTSTART '%s'", startValue)
+	}
+	end := start.AddDate(0, 0, 1)
+	if endValue != "" {
+		day, timed, err := parseICSDate(endValue)
+		if err != nil {
+			return fmt.Errorf("invalid DTEND '%s'", endValue)
+		}
+		end = day
+		if timed {
+			end = day.AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+	}
+
+	if rule == "" {
+		c.addDays(start, end)
+		return nil
+	}
+	event := yearlyEvent{month: start.Month(), day: start.Day(), days: daysBetween(start, end), from: start.Year()}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			if value != "YEARLY" {
+				return fmt.Errorf("unsupported RRULE '%s', only FREQ=YEARLY is supported", rule)
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count <= 0 {
+				return fmt.Errorf("invalid RRULE '%s'", rule)
+			}
+			event.end = event.from + count - 1
+		case "UNTIL":
+			until, _, err := parseICSDate(value)
+			if err != nil {
+				return fmt.Errorf("invalid RRULE '%s'", rule)
+			}
+			event.end = until.Year()
+		default:
+			return fmt.Errorf("unsupported RRULE '%s', only FREQ=YEARLY is supported", rule)
+		}
+	}
+	c.yearly = append(c.yearly, event)
+	return nil
+}
+
+// parseICSDate returns the day of an iCalendar DATE or DATE-TIME value and
+// whether it has a time. A timed value ending at midnight counts as a date.
+func parseICSDate(value string) (time.Time, bool, error) {
+	date, clock, timed := strings.Cut(value, "T")
+	day, err := time.Parse("20060102", date)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return day, timed && strings.TrimSuffix(clock, "Z") != "000000", nil
+}
+
+// addDays adds the days from start up to, but excluding, end.
+func (c *calendar) addDays(start, end time.Time) {
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		c.dates[day.Format(dateLayout)] = true
+	}
+}
+
+// contains reports whether the day of t, in its location, is in the calendar.
+func (c *calendar) contains(t time.Time) bool {
+	if c.dates[t.Format(dateLayout)] {
+		return true
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for _, event := range c.yearly {
+		// An event of the previous year may last into this one.
+		for year := t.Year() - 1; year <= t.Year(); year++ {
+			if year < event.from || (event.end != 0 && year > event.end) {
+				continue
+			}
+			start := time.Date(year, event.month, event.day, 0, 0, 0, 0, time.UTC)
+			if !day.Before(start) && day.Before(start.AddDate(0, 0, event.days)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fingerprint describes the days of the calendar, so that reloads notice
+// changed files.
+func (c *calendar) fingerprint() string {
+	dates := make([]string, 0, len(c.dates))
+	for date := range c.dates {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return fmt.Sprintf("%s %v %v", c.name, dates, c.yearly)
+}
+
+// resolveCalendars returns the calendars named by a comma-separated list.
+func resolveCalendars(names string, calendars map[string]*calendar) ([]*calendar, error) {
+	var result []*calendar
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		cal, ok := calendars[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown calendar '%s'", name)
+		}
+		result = append(result, cal)
+	}
+	return result, nil
+}
+
+// calendarBlock returns why the calendars of the task rule out a run at t,
+// or an empty string if they allow it. Days are those of the time zone of
+// the task.
+func (s *CronSchedule) calendarBlock(t time.Time) string {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	for _, cal := range s.skipOn {
+		if cal.contains(t) {
+			return fmt.Sprintf("%s is in calendar '%s'", t.Format(dateLayout), cal.name)
+		}
+	}
+	for _, cal := range s.onlyOn {
+		if cal.contains(t) {
+			return ""
+		}
+	}
+	if len(s.onlyOn) > 0 {
+		return fmt.Sprintf("%s is not in calendar %s", t.Format(dateLayout), calendarNames(s.onlyOn))
+	}
+	return ""
+}
+
+// calendarNames lists the names of calendars for messages.
+func calendarNames(calendars []*calendar) string {
+	names := make([]string, len(calendars))
+	for i, cal := range calendars {
+		names[i] = "'" + cal.name + "'"
+	}
+	return strings.Join(names, " or ")
+}
+
+// endOfDay returns the last instant of the day of t in the time zone of the
+// task.
+func (s *CronSchedule) endOfDay(t time.Time) time.Time {
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()).Add(-time.Nanosecond)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testICS is an iCalendar file with all-day, multi-day, timed and yearly events
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Christmas\r\n" +
+	"DTSTART;VALUE=DATE:20261225\r\n" +
+	"DTEND;VALUE=DATE:20261227\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Maintenance\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261107T220000\r\n" +
+	"DTEND;TZID=Europe/Berlin:2026110\r\n" +
+	" 8T040000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:New Year\r\n" +
+	"DTSTART;VALUE=DATE:20260101\r\n" +
+	"RRULE:FREQ=YEARLY;COUNT=3\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// writeCalendar writes a calendar file into a temporary directory
+func writeCalendar(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestReadCalendar verifies the days of date lists and iCalendar files
+func TestReadCalendar(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		included []string
+		excluded []string
+	}{
+		{
+			name:     "date_list",
+			file:     "holidays",
+			content:  "# Public holidays\n2026-12-25\n\n2026-12-31..2027-01-01 # New Year\n",
+			included: []string{"2026-12-25", "2026-12-31", "2027-01-01"},
+			excluded: []string{"2026-12-24", "2026-12-26", "2027-01-02"},
+		},
+		{
+			name:     "ics",
+			file:     "holidays.ics",
+			content:  testICS,
+			included: []string{"2026-12-25", "2026-12-26", "2026-11-07", "2026-11-08", "2026-01-01", "2028-01-01"},
+			excluded: []string{"2026-12-27", "2026-11-09", "2029-01-01", "2025-01-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := readCalendar("holidays", writeCalendar(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, date := range tt.included {
+				day, _ := time.Parse(dateLayout, date)
+				if !cal.contains(day.Add(12 * time.Hour)) {
+					t.Errorf("expected %s to be in the calendar", date)
+				}
+			}
+			for _, date := range tt.excluded {
+				day, _ := time.Parse(dateLayout, date)
+				if cal.contains(day.Add(12 * time.Hour)) {
+					t.Errorf("expected %s not to be in the calendar", date)
+				}
+			}
+		})
+	}
+}
+
+// TestReadCalendarErrors verifies invalid calendar files are rejected
+func TestReadCalendarErrors(t *testing.T) {
+	tests := []struct {
+		file        string
+		content     string
+		expectedErr string
+	}{
+		{"dates", "2026-12-25\nChristmas\n", "line 2: invalid date 'Christmas'"},
+		{"dates", "2026-12-31..2026-12-24\n", "line 1: invalid range"},
+		{"weekly.ics", "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20260105\nRRULE:FREQ=WEEKLY\nEND:VEVENT\n", "unsupported RRULE 'FREQ=WEEKLY'"},
+		{"broken.ics", "BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n", "invalid DTSTART 'tomorrow'"},
+	}
+
+	for _, tt := range tests {
+		_, err := readCalendar("test", writeCalendar(t, tt.file, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Errorf("%s: expected error containing '%s', got %v", tt.file, tt.expectedErr, err)
+		}
+	}
+}
+
+// TestCalendarSchedule verifies runs skip or are limited to calendar days
+func TestCalendarSchedule(t *testing.T) {
+	holidays := &calendar{name: "holidays", dates: map[string]bool{}}
+	holidays.addDays(time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 27, 0, 0, 0, 0, time.UTC))
+	workdays := &calendar{name: "workdays", dates: map[string]bool{}}
+	workdays.addDays(time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC))
+
+	task, _ := parseSchedule("0 9 * * *")
+	task.location = time.UTC
+	task.skipOn = []*calendar{holidays}
+	from := time.Date(2026, 12, 23, 12, 0, 0, 0, time.UTC)
+	if got := task.next(from).Format(time.RFC3339); got != "2026-12-27T09:00:00Z" {
+		t.Errorf("expected the holidays to be skipped, got %s", got)
+	}
+	if reason := task.calendarBlock(time.Date(2026, 12, 25, 9, 0, 0, 0, time.UTC)); reason != "2026-12-25 is in calendar 'holidays'" {
+		t.Errorf("unexpected reason: %q", reason)
+	}
+
+	task.skipOn = nil
+	task.onlyOn = []*calendar{workdays}
+	if got := task.next(time.Date(2026, 12, 22, 12, 0, 0, 0, time.UTC)).Format(time.RFC3339); got != "2026-12-23T09:00:00Z" {
+		t.Errorf("unexpected next run: %s", got)
+	}
+	if got := task.next(time.Date(2026, 12, 23, 10, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("expected no run after the last calendar day, got %v", got)
+	}
+
+	// Days are those of the time zone of the task.
+	tokyo := time.FixedZone("JST", 9*3600)
+	task.location = tokyo
+	task.onlyOn = nil
+	task.skipOn = []*calendar{holidays}
+	if task.calendarBlock(time.Date(2026, 12, 23, 20, 0, 0, 0, time.UTC)) == "" {
+		t.Error("expected 20:00 UTC to be on December 24 in Tokyo")
+	}
+
+	every, _ := parseSchedule("@every 12h")
+	every.skipOn = []*calendar{holidays}
+	times := every.upcoming(time.Date(2026, 12, 23, 0, 0, 0, 0, time.UTC), 3)
+	expected := []string{"2026-12-23T12:00:00Z", "2026-12-27T00:00:00Z", "2026-12-27T12:00:00Z"}
+	if len(times) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, times)
+	}
+	for i, want := range expected {
+		if got := times[i].UTC().Format(time.RFC3339); got != want {
+			t.Errorf("run %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+// TestRunTaskCalendarSkip verifies skipped runs are counted and not executed
+func TestRunTaskCalendarSkip(t *testing.T) {
+	useTestHistory(t)
+	originalRunner := defaultCommandRunner
+	setCommandRunner(&MockCommandRunner{})
+	defer setCommandRunner(originalRunner)
+
+	today := &calendar{name: "today", dates: map[string]bool{}}
+	now := time.Now()
+	today.addDays(now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+
+	task, _ := parseSchedule("* * * * *")
+	task.name = "report"
+	task.command = "echo report"
+	task.skipOn = []*calendar{today}
+
+	runTask(task, triggerScheduled)
+	runTask(task, triggerScheduled)
+	if runs, _ := runHistory.list("report", 0); len(runs) != 0 {
+		t.Errorf("expected no runs, got %d", len(runs))
+	}
+	if n := task.state.skippedRuns(); n != 2 {
+		t.Errorf("expected 2 skipped runs, got %d", n)
+	}
+	if info := describeTask(task, now); info.Skipped != 2 {
+		t.Errorf("expected the API to report 2 skipped runs, got %d", info.Skipped)
+	}
+
+	runTask(task, triggerManual)
+	if runs, _ := runHistory.list("report", 0); len(runs) != 1 {
+		t.Errorf("expected a manual run, got %d", len(runs))
+	}
+}
+
+// TestLoadConfigurationCalendars verifies calendars and the skip_on and only_on options
+func TestLoadConfigurationCalendars(t *testing.T) {
+	holidays := writeCalendar(t, "holidays.ics", testICS)
+	business := writeCalendar(t, "business_days", "2026-11-02..2026-11-06\n")
+	path := writeConfig(t, "gron.yaml", `
+calendars:
+  holidays: `+holidays+`
+defaults:
+  skip_on: holidays
+tasks:
+  payroll:
+    schedule: "0 9 * * *"
+    command: payroll.sh
+    only_on: business
+  cleanup:
+    schedule: "0 3 * * *"
+    command: cleanup.sh
+    skip_on: holidays, maintenance
+`)
+	environ := []string{"GRON_CALENDAR_BUSINESS=" + business}
+
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 1 || !strings.Contains(config.errors[0].Error(), "tasks.cleanup: skip_on: unknown calendar 'maintenance'") {
+		t.Errorf("unexpected errors: %v", config.errors)
+	}
+	if len(config.tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(config.tasks))
+	}
+	payroll := config.tasks[0]
+	if len(payroll.skipOn) != 1 || payroll.skipOn[0].name != "holidays" || len(payroll.onlyOn) != 1 || payroll.onlyOn[0].name != "business" {
+		t.Errorf("unexpected calendars: %v, %v", payroll.skipOn, payroll.onlyOn)
+	}
+
+	if _, err := loadConfiguration("", nil, []string{"GRON_CALENDAR_MISSING=/nonexistent/holidays"}); err == nil || !strings.Contains(err.Error(), "calendar 'missing'") {
+		t.Errorf("expected a missing calendar file to be reported, got %v", err)
+	}
+}
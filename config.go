@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultRetryDelay is the delay between attempts of a task with retries.
+const defaultRetryDelay = 10 * time.Second
+
+// taskNamePattern matches valid task names. Names must be usable as part of
+// GRON_* environment variable names.
+var taskNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// fileConfig is the structure of a YAML or JSON configuration file.
+type fileConfig struct {
+	Defaults  optionsConfig         `yaml:"defaults" json:"defaults"`
+	Tasks     map[string]taskConfig `yaml:"tasks" json:"tasks"`
+	Webhooks  []webhookConfig       `yaml:"webhooks" json:"webhooks"`
+	Mail      *mailConfig           `yaml:"mail" json:"mail"`
+	SMTP      *smtpFileConfig       `yaml:"smtp" json:"smtp"`
+	Aliases   map[string]string     `yaml:"aliases" json:"aliases"`
+	Calendars map[string]string     `yaml:"calendars" json:"calendars"`
+	calendars map[string]*calendar  // Loaded calendars by name.
+}
+
+// optionsConfig holds the execution options of a task. The options under
+// "defaults" apply to every task that does not set them itself.
+type optionsConfig struct {
+	Timeout      string            `yaml:"timeout" json:"timeout"`
+	Retries      *int              `yaml:"retries" json:"retries"`
+	RetryDelay   string            `yaml:"retry_delay" json:"retry_delay"`
+	Dir          string            `yaml:"dir" json:"dir"`
+	User         string            `yaml:"user" json:"user"`
+	Shell        string            `yaml:"shell" json:"shell"`
+	Timezone     string            `yaml:"timezone" json:"timezone"`
+	StartDelay   string            `yaml:"start_delay" json:"start_delay"`
+	Jitter       string            `yaml:"jitter" json:"jitter"`
+	RunOnStart   *bool             `yaml:"run_on_start" json:"run_on_start"`
+	InitialDelay string            `yaml:"initial_delay" json:"initial_delay"`
+	Missed       string            `yaml:"missed" json:"missed"`
+	NotBefore    string            `yaml:"not_before" json:"not_before"`
+	NotAfter     string            `yaml:"not_after" json:"not_after"`
+	SkipOn       string            `yaml:"skip_on" json:"skip_on"`
+	OnlyOn       string            `yaml:"only_on" json:"only_on"`
+	Window       string            `yaml:"window" json:"window"`
+	Enabled      *bool             `yaml:"enabled" json:"enabled"`
+	Env          map[string]string `yaml:"env" json:"env"`
+}
+
+// taskConfig describes a single task in the configuration file.
+type taskConfig struct {
+	Schedule      string `yaml:"schedule" json:"schedule"`
+	Command       string `yaml:"command" json:"command"`
+	Stdin         string `yaml:"stdin" json:"stdin"`
+	optionsConfig `yaml:",inline"`
+	Webhooks      []webhookConfig `yaml:"webhooks" json:"webhooks"`
+	Mail          *mailConfig     `yaml:"mail" json:"mail"`
+	Ping          *pingConfig     `yaml:"ping" json:"ping"`
+	position      string          // "file:line" of the definition, if known.
+	dayMatchAny   bool            // Either day field matching is enough, as in crontabs.
+}
+
+// webhookConfig describes a webhook target.
+type webhookConfig struct {
+	URL      string `yaml:"url" json:"url"`
+	Events   string `yaml:"events" json:"events"`
+	Format   string `yaml:"format" json:"format"`
+	Template string `yaml:"template" json:"template"`
+	Retries  *int   `yaml:"retries" json:"retries"`
+}
+
+// mailConfig describes the recipients of mail reports.
+type mailConfig struct {
+	To []string `yaml:"to" json:"to"`
+	On string   `yaml:"on" json:"on"`
+}
+
+// smtpFileConfig describes the SMTP server used to send mail reports.
+type smtpFileConfig struct {
+	Host          string `yaml:"host" json:"host"`
+	Port          int    `yaml:"port" json:"port"`
+	Username      string `yaml:"username" json:"username"`
+	Password      string `yaml:"password" json:"password"`
+	From          string `yaml:"from" json:"from"`
+	Security      string `yaml:"security" json:"security"`
+	Auth          string `yaml:"auth" json:"auth"`
+	BatchInterval string `yaml:"batch_interval" json:"batch_interval"`
+}
+
+// pingConfig describes the monitor pinged around each run of a task.
+type pingConfig struct {
+	URL      string `yaml:"url" json:"url"`
+	ExitCode bool   `yaml:"exit_code" json:"exit_code"`
+	Output   bool   `yaml:"output" json:"output"`
+	Timeout  string `yaml:"timeout" json:"timeout"`
+}
+
+// readConfigFile reads a configuration file. Files ending in ".json" are
+// decoded as JSON, anything else as YAML. Unknown keys are rejected.
+func readConfigFile(path string) (*fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &fileConfig{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if err == nil {
+			cfg.setPositions(path, data)
+		}
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// setPositions records the line of each task definition in a YAML file, so
+// that errors can point at it.
+func (c *fileConfig) setPositions(path string, data []byte) {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "tasks" {
+			continue
+		}
+		tasks := root.Content[i+1]
+		for j := 0; j+1 < len(tasks.Content); j += 2 {
+			key := tasks.Content[j]
+			if tc, ok := c.Tasks[key.Value]; ok {
+				tc.position = fmt.Sprintf("%s:%d", path, key.Line)
+				c.Tasks[key.Value] = tc
+			}
+		}
+	}
+}
+
+// withDefaults returns the options with unset values taken from defaults.
+// Environment variables from both are merged, the task's own taking precedence.
+func (o optionsConfig) withDefaults(defaults optionsConfig) optionsConfig {
+	if o.Timeout == "" {
+		o.Timeout = defaults.Timeout
+	}
+	if o.Retries == nil {
+		o.Retries = defaults.Retries
+	}
+	if o.RetryDelay == "" {
+		o.RetryDelay = defaults.RetryDelay
+	}
+	if o.Dir == "" {
+		o.Dir = defaults.Dir
+	}
+	if o.User == "" {
+		o.User = defaults.User
+	}
+	if o.Shell == "" {
+		o.Shell = defaults.Shell
+	}
+	if o.Timezone == "" {
+		o.Timezone = defaults.Timezone
+	}
+	if o.StartDelay == "" {
+		o.StartDelay = defaults.StartDelay
+	}
+	if o.Jitter == "" {
+		o.Jitter = defaults.Jitter
+	}
+	if o.RunOnStart == nil {
+		o.RunOnStart = defaults.RunOnStart
+	}
+	if o.InitialDelay == "" {
+		o.InitialDelay = defaults.InitialDelay
+	}
+	if o.Missed == "" {
+		o.Missed = defaults.Missed
+	}
+	if o.NotBefore == "" {
+		o.NotBefore = defaults.NotBefore
+	}
+	if o.NotAfter == "" {
+		o.NotAfter = defaults.NotAfter
+	}
+	if o.SkipOn == "" {
+		o.SkipOn = defaults.SkipOn
+	}
+	if o.OnlyOn == "" {
+		o.OnlyOn = defaults.OnlyOn
+	}
+	if o.Window == "" {
+		o.Window = defaults.Window
+	}
+	if o.Enabled == nil {
+		o.Enabled = defaults.Enabled
+	}
+	env := make(map[string]string)
+	for k, v := range defaults.Env {
+		env[k] = v
+	}
+	for k, v := range o.Env {
+		env[k] = v
+	}
+	o.Env = env
+	return o
+}
+
+// apply validates the options and sets them on a task.
+func (o optionsConfig) apply(task *CronSchedule) error {
+	opts := commandOptions{dir: o.Dir, user: o.User, shell: o.Shell}
+
+	if o.Timeout != "" {
+		d, err := time.ParseDuration(o.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout '%s'", o.Timeout)
+		}
+		opts.timeout = d
+	}
+
+	retries := 0
+	if o.Retries != nil {
+		if *o.Retries < 0 {
+			return fmt.Errorf("retries must not be negative, got %d", *o.Retries)
+		}
+		retries = *o.Retries
+	}
+	retryDelay := defaultRetryDelay
+	if o.RetryDelay != "" {
+		d, err := time.ParseDuration(o.RetryDelay)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid retry_delay '%s'", o.RetryDelay)
+		}
+		retryDelay = d
+	}
+
+	var startDelay time.Duration
+	if o.StartDelay != "" {
+		d, err := time.ParseDuration(o.StartDelay)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid start_delay '%s'", o.StartDelay)
+		}
+		startDelay = d
+	}
+
+	var jitter time.Duration
+	if o.Jitter != "" {
+		d, err := time.ParseDuration(o.Jitter)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid jitter '%s'", o.Jitter)
+		}
+		jitter = d
+	}
+
+	var initialDelay time.Duration
+	if o.InitialDelay != "" {
+		d, err := time.ParseDuration(o.InitialDelay)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid initial_delay '%s'", o.InitialDelay)
+		}
+		initialDelay = d
+	}
+
+	switch o.Missed {
+	case "", missedSkip, missedRun:
+	default:
+		return fmt.Errorf("invalid missed '%s', expected %s or %s", o.Missed, missedSkip, missedRun)
+	}
+
+	var window *runWindow
+	if o.Window != "" {
+		var err error
+		if window, err = parseWindow(o.Window); err != nil {
+			return fmt.Errorf("invalid window '%s': %v", o.Window, err)
+		}
+	}
+
+	// A time zone written in the schedule takes precedence.
+	location := task.location
+	if o.Timezone != "" {
+		zone, err := time.LoadLocation(o.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone '%s'", o.Timezone)
+		}
+		if location == nil {
+			location = zone
+		}
+	}
+
+	var notBefore, notAfter time.Time
+	if o.NotBefore != "" {
+		var err error
+		if notBefore, err = parseBound(o.NotBefore, location, false); err != nil {
+			return fmt.Errorf("invalid not_before '%s': %v", o.NotBefore, err)
+		}
+	}
+	if o.NotAfter != "" {
+		var err error
+		if notAfter, err = parseBound(o.NotAfter, location, true); err != nil {
+			return fmt.Errorf("invalid not_after '%s': %v", o.NotAfter, err)
+		}
+	}
+	if !notBefore.IsZero() && !notAfter.IsZero() && notAfter.Before(notBefore) {
+		return fmt.Errorf("not_after %s is before not_before %s", o.NotAfter, o.NotBefore)
+	}
+
+	keys := make([]string, 0, len(o.Env))
+	for k := range o.Env {
+		if k == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("invalid environment variable name '%s'", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		opts.env = append(opts.env, k+"="+o.Env[k])
+	}
+
+	task.exec = opts
+	task.retries = retries
+	task.retryDelay = retryDelay
+	task.location = location
+	task.startDelay = startDelay
+	task.jitter = jitter
+	task.initialDelay = initialDelay
+	task.runOnStart = o.RunOnStart != nil && *o.RunOnStart
+	task.runMissed = o.Missed == missedRun
+	task.notBefore = notBefore
+	task.notAfter = notAfter
+	task.window = window
+	return nil
+}
+
+// sortedTaskNames returns the names of tasks in order.
+func sortedTaskNames(tasks map[string]taskConfig) []string {
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildTasks creates the tasks described by the configuration file, in name
+// order. Invalid tasks are skipped and the errors returned alongside the
+// tasks that were built successfully.
+func (c *fileConfig) buildTasks() ([]*CronSchedule, []error) {
+	var tasks []*CronSchedule
+	var errs []error
+	for _, name := range sortedTaskNames(c.Tasks) {
+		tc := c.Tasks[name]
+		task, err := c.buildTask(name, tc)
+		if err != nil {
+			if tc.position != "" {
+				err = fmt.Errorf("%s: tasks.%s: %v", tc.position, name, err)
+			} else {
+				err = fmt.Errorf("tasks.%s: %v", name, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, errs
+}
+
+// buildTask creates a single task described by the configuration file.
+// It returns nil without an error if the task is disabled.
+func (c *fileConfig) buildTask(name string, tc taskConfig) (*CronSchedule, error) {
+	if !taskNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid task name, use lowercase letters, digits and underscores")
+	}
+	if strings.TrimSpace(tc.Command) == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	task, err := parseScheduleFor(expandAlias(tc.Schedule, c.Aliases), name)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.Aliases[strings.TrimSpace(tc.Schedule)]; ok {
+		task.spec = strings.TrimSpace(tc.Schedule)
+	}
+	task.name = name
+	task.command = tc.Command
+	task.dayMatchAny = tc.dayMatchAny
+
+	options := tc.optionsConfig.withDefaults(c.Defaults)
+	if err := options.apply(task); err != nil {
+		return nil, err
+	}
+	if !task.isEvery {
+		if tc.InitialDelay != "" {
+			return nil, fmt.Errorf("initial_delay only applies to @every tasks")
+		}
+		// A default initial delay only applies to @every tasks.
+		task.initialDelay = 0
+	}
+	if !task.isAt {
+		if tc.Missed != "" {
+			return nil, fmt.Errorf("missed only applies to @at tasks")
+		}
+		task.runMissed = false
+	}
+	if task.skipOn, err = resolveCalendars(options.SkipOn, c.calendars); err != nil {
+		return nil, fmt.Errorf("skip_on: %v", err)
+	}
+	if task.onlyOn, err = resolveCalendars(options.OnlyOn, c.calendars); err != nil {
+		return nil, fmt.Errorf("only_on: %v", err)
+	}
+	// Log tasks that expired before they were loaded; they can still be
+	// run by hand.
+	task.checkExpired(time.Now())
+	if options.Enabled != nil && !*options.Enabled {
+		log.Printf("Task '%s' is disabled", name)
+		return nil, nil
+	}
+	task.exec.stdin = tc.Stdin
+
+	for i, wc := range tc.Webhooks {
+		target, err := wc.build()
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %v", i, err)
+		}
+		task.webhooks = append(task.webhooks, target)
+	}
+
+	if tc.Ping != nil {
+		timeout := defaultPingTimeout
+		if tc.Ping.Timeout != "" {
+			if timeout, err = time.ParseDuration(tc.Ping.Timeout); err != nil {
+				return nil, fmt.Errorf("ping: invalid timeout '%s'", tc.Ping.Timeout)
+			}
+		}
+		if task.ping, err = newPingTarget(tc.Ping.URL, tc.Ping.ExitCode, tc.Ping.Output, timeout); err != nil {
+			return nil, fmt.Errorf("ping: %v", err)
+		}
+	}
+
+	return task, nil
+}
+
+// build creates the webhook target described by the configuration.
+func (wc webhookConfig) build() (*webhookTarget, error) {
+	retries := 3
+	if wc.Retries != nil {
+		retries = *wc.Retries
+	}
+	return newWebhookTarget(wc.URL, wc.Events, wc.Format, wc.Template, retries)
+}
+
+// mailEnviron translates the mail settings of the configuration file into the
+// equivalent GRON_MAIL_* and GRON_SMTP_* environment entries, so that they are
+// validated like, and can be overridden by, the environment.
+func (c *fileConfig) mailEnviron() []string {
+	var environ []string
+	if c.Mail != nil {
+		environ = append(environ, "GRON_MAIL_TO="+strings.Join(c.Mail.To, ","))
+		if c.Mail.On != "" {
+			environ = append(environ, "GRON_MAIL_ON="+c.Mail.On)
+		}
+	}
+
+	for _, name := range sortedTaskNames(c.Tasks) {
+		mail := c.Tasks[name].Mail
+		if mail == nil || !taskNamePattern.MatchString(name) {
+			continue
+		}
+		prefix := "GRON_MAIL_" + strings.ToUpper(name) + "_"
+		if mail.To != nil {
+			environ = append(environ, prefix+"TO="+strings.Join(mail.To, ","))
+		}
+		if mail.On != "" {
+			environ = append(environ, prefix+"ON="+mail.On)
+		}
+	}
+
+	if s := c.SMTP; s != nil {
+		settings := []struct{ key, value string }{
+			{"HOST", s.Host},
+			{"USERNAME", s.Username},
+			{"PASSWORD", s.Password},
+			{"FROM", s.From},
+			{"SECURITY", s.Security},
+			{"AUTH", s.Auth},
+			{"BATCH_INTERVAL", s.BatchInterval},
+		}
+		if s.Port != 0 {
+			settings = append(settings, struct{ key, value string }{"PORT", strconv.Itoa(s.Port)})
+		}
+		for _, setting := range settings {
+			if setting.value != "" {
+				environ = append(environ, "GRON_SMTP_"+setting.key+"="+setting.value)
+			}
+		}
+	}
+	return environ
+}
+
+// configuration is the merged result of the environment and the
+// configuration file.
+type configuration struct {
+	tasks    []*CronSchedule
+	webhooks []*webhookTarget // Global webhook targets.
+	mailer   *mailer          // Nil if mail reports are not configured.
+	errors   []error          // Invalid tasks that were skipped.
+}
+
+// loadConfiguration loads the tasks and notification targets from the
+// environment and, if path is not empty, from a YAML or JSON configuration
+// file, and the tasks of the given crontab files and cron.d directories. Tasks
+// from all sources are merged; the defaults of the file apply to every task,
+// and notification settings in the environment take precedence over the file.
+// Invalid tasks are skipped and reported in the result; unreadable files or
+// invalid global settings are returned as an error.
+func loadConfiguration(path string, crontabs []string, environ []string) (*configuration, error) {
+	cfg := &fileConfig{}
+	if path != "" {
+		var err error
+		if cfg, err = readConfigFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if err := cfg.Defaults.apply(&CronSchedule{}); err != nil {
+		return nil, fmt.Errorf("defaults: %v", err)
+	}
+	aliases, err := loadAliases(environ, cfg.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Aliases = aliases
+	if cfg.calendars, err = loadCalendars(environ, cfg.Calendars); err != nil {
+		return nil, err
+	}
+
+	cronTasks, cronErrs, err := readCrontabs(crontabs, cfg.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SMTP == nil && !hasEnv(environ, "GRON_SMTP_HOST") {
+		// Like cron without a mail transfer agent, ignore MAILTO
+		// rather than refusing to start.
+		for name, task := range cronTasks {
+			if task.Mail != nil && len(task.Mail.To) > 0 {
+				log.Printf("Ignoring MAILTO of crontab task '%s': GRON_SMTP_HOST is not set", name)
+				task.Mail = nil
+				cronTasks[name] = task
+			}
+		}
+	}
+	if cfg.Tasks == nil {
+		cfg.Tasks = make(map[string]taskConfig)
+	}
+	for _, name := range sortedTaskNames(cronTasks) {
+		if _, ok := cfg.Tasks[name]; ok {
+			cronErrs = append(cronErrs, fmt.Errorf("tasks.%s: task is also defined by a crontab", name))
+			continue
+		}
+		cfg.Tasks[name] = cronTasks[name]
+	}
+
+	tasks, errs := buildEnvTasks(environ, cfg)
+	names := make(map[string]bool)
+	for _, task := range tasks {
+		names[task.name] = true
+	}
+
+	fileTasks, fileErrs := cfg.buildTasks()
+	errs = append(append(errs, cronErrs...), fileErrs...)
+	for _, task := range fileTasks {
+		if names[task.name] {
+			errs = append(errs, fmt.Errorf("tasks.%s: task is also defined by TASK_%s", task.name, strings.ToUpper(task.name)))
+			continue
+		}
+		names[task.name] = true
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].name < tasks[j].name })
+
+	config := &configuration{tasks: tasks, errors: errs}
+	for i, wc := range cfg.Webhooks {
+		target, err := wc.build()
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %v", i, err)
+		}
+		config.webhooks = append(config.webhooks, target)
+	}
+
+	webhooks, err := loadWebhooks(environ, tasks)
+	if err != nil {
+		return nil, err
+	}
+	config.webhooks = append(config.webhooks, webhooks...)
+
+	if err := loadPings(environ, tasks); err != nil {
+		return nil, err
+	}
+
+	if config.mailer, err = loadMailer(append(cfg.mailEnviron(), environ...), tasks); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// hasEnv reports whether a variable is set in a list of "KEY=value" entries.
+func hasEnv(environ []string, name string) bool {
+	for _, env := range environ {
+		if strings.HasPrefix(env, name+"=") {
+			return true
+		}
+	}
+	return false
+}
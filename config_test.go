@@ -0,0 +1,292 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a configuration file with the given name to a temporary directory
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+const testYAMLConfig = `
+defaults:
+  timeout: 30m
+  retries: 1
+  env:
+    TZ: UTC
+tasks:
+  backup_db:
+    schedule: "0 2 * * *"
+    command: pg_dump app > /backup/app.sql
+    timeout: 2h
+    retries: 3
+    retry_delay: 1m
+    dir: /backup
+    user: postgres
+    env:
+      PGHOST: db
+    webhooks:
+      - url: https://hooks.example.com/backup
+        format: slack
+    mail:
+      to: [dba@example.com]
+      on: always
+  poll:
+    schedule: "@every 5m"
+    command: curl -fsS http://app/poll
+    retries: 0
+    ping:
+      url: https://hc-ping.com/uuid
+webhooks:
+  - url: https://hooks.example.com/all
+    events: failure,recovery
+mail:
+  to: [ops@example.com]
+smtp:
+  host: smtp.example.com
+  port: 587
+  batch_interval: 5m
+`
+
+// TestLoadConfigurationYAML verifies tasks, defaults and notifications from a YAML file
+func TestLoadConfigurationYAML(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", testYAMLConfig)
+	environ := []string{"TASK_REPORT=@daily /usr/bin/report.sh"}
+
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 0 {
+		t.Fatalf("unexpected task errors: %v", config.errors)
+	}
+	if len(config.tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(config.tasks))
+	}
+
+	backup, poll, report := config.tasks[0], config.tasks[1], config.tasks[2]
+	if report.name != "report" || report.exec.timeout != 30*time.Minute || report.retries != 1 {
+		t.Errorf("expected defaults to apply to environment tasks, got %+v", report.exec)
+	}
+	if backup.name != "backup_db" || backup.spec != "0 2 * * *" || backup.command != "pg_dump app > /backup/app.sql" {
+		t.Errorf("unexpected backup task: %s %q %q", backup.name, backup.spec, backup.command)
+	}
+	if backup.exec.timeout != 2*time.Hour || backup.retries != 3 || backup.retryDelay != time.Minute {
+		t.Errorf("unexpected backup options: %+v, retries %d, delay %v", backup.exec, backup.retries, backup.retryDelay)
+	}
+	if backup.exec.dir != "/backup" || backup.exec.user != "postgres" {
+		t.Errorf("unexpected backup options: %+v", backup.exec)
+	}
+	if strings.Join(backup.exec.env, " ") != "PGHOST=db TZ=UTC" {
+		t.Errorf("expected merged environment, got %v", backup.exec.env)
+	}
+	if len(backup.webhooks) != 1 || backup.mail == nil || backup.mail.on != mailOnAlways {
+		t.Errorf("unexpected backup notifications: %v, %+v", backup.webhooks, backup.mail)
+	}
+	if !poll.isEvery || poll.retries != 0 || poll.retryDelay != defaultRetryDelay || poll.ping == nil {
+		t.Errorf("unexpected poll task: %+v", poll)
+	}
+
+	if len(config.webhooks) != 1 || !config.webhooks[0].wants(eventRecovery) {
+		t.Errorf("unexpected global webhooks: %v", config.webhooks)
+	}
+	if config.mailer == nil || config.mailer.config.port != 587 || config.mailer.interval != 5*time.Minute {
+		t.Fatalf("unexpected mailer: %+v", config.mailer)
+	}
+	if config.mailer.global.to[0] != "ops@example.com" {
+		t.Errorf("unexpected global mail target: %+v", config.mailer.global)
+	}
+}
+
+// TestLoadConfigurationJSON verifies JSON files and environment precedence
+func TestLoadConfigurationJSON(t *testing.T) {
+	path := writeConfig(t, "gron.json", `{
+		"tasks": {
+			"cleanup": {"schedule": "@hourly", "command": "rm -rf /tmp/cache", "timeout": "5m"}
+		},
+		"mail": {"to": ["ops@example.com"]},
+		"smtp": {"host": "smtp.example.com"}
+	}`)
+	environ := []string{"GRON_MAIL_TO=oncall@example.com", "GRON_SMTP_HOST=relay.example.com"}
+
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.tasks) != 1 || config.tasks[0].exec.timeout != 5*time.Minute {
+		t.Fatalf("unexpected tasks: %v", config.tasks)
+	}
+	if config.mailer.global.to[0] != "oncall@example.com" || config.mailer.config.host != "relay.example.com" {
+		t.Errorf("expected environment to override the file, got %+v", config.mailer)
+	}
+}
+
+// TestLoadConfigurationTaskErrors verifies invalid and duplicate tasks are skipped
+func TestLoadConfigurationTaskErrors(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+tasks:
+  backup:
+    schedule: "@daily"
+    command: backup.sh
+  no_command:
+    schedule: "@daily"
+  bad_schedule:
+    schedule: "61 * * * *"
+    command: echo
+  bad_timeout:
+    schedule: "@daily"
+    command: echo
+    timeout: soon
+  Bad-Name:
+    schedule: "@daily"
+    command: echo
+  ok:
+    schedule: "*/5 * * * *"
+    command: echo ok
+`)
+	environ := []string{"TASK_BACKUP=@hourly backup.sh"}
+
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.tasks) != 2 || config.tasks[0].spec != "@hourly" || config.tasks[1].name != "ok" {
+		t.Errorf("expected environment backup and ok tasks, got %d tasks", len(config.tasks))
+	}
+	if len(config.errors) != 5 {
+		t.Fatalf("expected 5 errors, got %d: %v", len(config.errors), config.errors)
+	}
+	for _, err := range config.errors {
+		if !strings.Contains(err.Error(), "tasks.") {
+			t.Errorf("expected error to name the task, got %v", err)
+		}
+	}
+	want := path + ":8: tasks.bad_schedule: field 1 (minute) '61': value 61 out of range 0-59"
+	found := false
+	for _, err := range config.errors {
+		found = found || err.Error() == want
+	}
+	if !found {
+		t.Errorf("expected error %q, got %v", want, config.errors)
+	}
+}
+
+// TestLoadConfigurationInvalid verifies errors that reject the whole configuration
+func TestLoadConfigurationInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown_key", "gron.yaml", "tasks:\n  a:\n    schedule: '@daily'\n    command: echo\n    timeuot: 5m\n"},
+		{"unknown_json_key", "gron.json", `{"task": {}}`},
+		{"syntax", "gron.yaml", "tasks: [\n"},
+		{"bad_defaults", "gron.yaml", "defaults:\n  retries: -1\n"},
+		{"bad_webhook", "gron.yaml", "webhooks:\n  - url: https://x\n    events: sometimes\n"},
+		{"mail_without_smtp", "gron.yaml", "mail:\n  to: [ops@example.com]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file, tt.content)
+			if _, err := loadConfiguration(path, nil, nil); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := loadConfiguration(filepath.Join(t.TempDir(), "missing.yaml"), nil, nil); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+// TestRunTaskRetries verifies failed runs are retried and every attempt recorded
+func TestRunTaskRetries(t *testing.T) {
+	mockRunner := &MockCommandRunner{ShouldFail: true, ReturnError: errTestCommand}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+	useTestHistory(t)
+
+	stub := newWebhookStub(t, 0)
+	task := newTestRegistry().get("backup")
+	task.retries = 2
+	target, _ := newWebhookTarget(stub.URL, "failure", "", "", 0)
+	task.webhooks = []*webhookTarget{target}
+
+	runTask(task, triggerScheduled)
+
+	if len(mockRunner.Commands) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(mockRunner.Commands))
+	}
+	runs, _ := runHistory.list("backup", 0)
+	if len(runs) != 3 || runs[0].Trigger != triggerRetry || runs[2].Trigger != triggerScheduled {
+		t.Errorf("unexpected recorded attempts: %+v", runs)
+	}
+	if bodies := stub.received(t, 1); len(bodies) != 1 {
+		t.Errorf("expected a single notification, got %d", len(bodies))
+	}
+}
+
+// TestLoadConfigurationStartOptions verifies run on start, initial delays and @reboot tasks
+func TestLoadConfigurationStartOptions(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+defaults:
+  initial_delay: 1m
+tasks:
+  warm:
+    schedule: "@every 1d"
+    command: warm.sh
+    run_on_start: true
+    initial_delay: 30s
+  poll:
+    schedule: "@every 5m"
+    command: poll.sh
+  report:
+    schedule: "@daily"
+    command: report.sh
+  bad:
+    schedule: "@daily"
+    command: echo
+    initial_delay: 5m
+`)
+	environ := []string{"TASK_SETUP=@reboot setup.sh", "TASK_SETUP_RUN_ON_START=false", "TASK_CACHE=@hourly cache.sh", "TASK_CACHE_RUN_ON_START=true"}
+
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 1 || !strings.Contains(config.errors[0].Error(), "tasks.bad: initial_delay only applies to @every tasks") {
+		t.Errorf("expected an error for bad, got %v", config.errors)
+	}
+
+	tasks := make(map[string]*CronSchedule)
+	for _, task := range config.tasks {
+		tasks[task.name] = task
+	}
+	if warm := tasks["warm"]; !warm.runOnStart || warm.initialDelay != 30*time.Second {
+		t.Errorf("unexpected warm task: %v, %v", warm.runOnStart, warm.initialDelay)
+	}
+	if poll := tasks["poll"]; poll.runOnStart || poll.initialDelay != time.Minute {
+		t.Errorf("expected the default initial delay, got %v", poll.initialDelay)
+	}
+	if report := tasks["report"]; report.initialDelay != 0 {
+		t.Errorf("expected no initial delay for a cron task, got %v", report.initialDelay)
+	}
+	if setup := tasks["setup"]; !setup.isReboot || setup.runOnStart {
+		t.Errorf("unexpected setup task: %+v", setup)
+	}
+	if cache := tasks["cache"]; !cache.runOnStart {
+		t.Error("expected TASK_CACHE_RUN_ON_START to be applied")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// defaultControlSocket is where the control socket is created unless
+// GRON_CONTROL_SOCKET is set. Setting it to an empty value disables the socket.
+const defaultControlSocket = "/tmp/gron.sock"
+
+// controlSocketPath returns the configured control socket path.
+func controlSocketPath() string {
+	if path, ok := os.LookupEnv("GRON_CONTROL_SOCKET"); ok {
+		return path
+	}
+	return defaultControlSocket
+}
+
+// startControlSocket serves the admin API over a Unix domain socket at path.
+// The socket is only accessible to the user gron runs as; no token is required.
+func startControlSocket(path string, reg *taskRegistry) (*http.Server, error) {
+	// Refuse to steal the socket of another running instance, but clean up
+	// a stale one left behind by a previous process.
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("control socket %s is already in use", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Restrict the socket to the owner. The umask is left alone, as it is
+	// shared by the whole process; connecting needs write permission, which
+	// the usual umask already withholds from others until the chmod.
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	server := &http.Server{Handler: adminHandler(reg, ""), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Control socket stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Control socket listening on %s", path)
+	return server, nil
+}
+
+// controlClient talks to a running gron over its control socket.
+type controlClient struct {
+	http *http.Client
+}
+
+// newControlClient creates a client connected to the socket at path.
+func newControlClient(path string, timeout time.Duration) *controlClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &controlClient{http: &http.Client{Transport: transport, Timeout: timeout}}
+}
+
+// do sends a request to the control socket and decodes the JSON response into out.
+func (c *controlClient) do(method, path string, out interface{}) error {
+	req, err := http.NewRequest(method, "http://gron"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s", apiErr.Error)
+		}
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	if out != nil {
+		return json.Unmarshal(body, out)
+	}
+	return nil
+}
+
+// ctlUsage describes the ctl subcommands.
+const ctlUsage = `Usage: gron ctl [-socket path] <command> [task]
+
+Commands:
+  list           List tasks with schedule, next run and last result
+  show <task>    Show a single task
+  run <task>     Run a task now
+  pause <task>   Pause scheduled runs of a task
+  resume <task>  Resume scheduled runs of a task
+  logs <task>    Show the output of recent runs of a task
+  history <task> Show recent runs of a task
+`
+
+// runCtl implements the "ctl" subcommand.
+func runCtl(args []string) int {
+	return ctl(args, os.Stdout, os.Stderr)
+}
+
+// ctl runs a ctl subcommand writing its output to stdout and stderr.
+func ctl(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, ctlUsage) }
+	socket := fs.String("socket", controlSocketPath(), "path of the control socket")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	command := fs.Arg(0)
+	client := newControlClient(*socket, *timeout)
+
+	if command == "list" {
+		var tasks []taskInfo
+		if err := client.do("GET", "/tasks", &tasks); err != nil {
+			fmt.Fprintf(stderr, "ctl: %v\n", err)
+			return 1
+		}
+		printTasks(stdout, tasks)
+		return 0
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	name := fs.Arg(1)
+
+	var err error
+	switch command {
+	case "show":
+		var task taskInfo
+		if err = client.do("GET", "/tasks/"+name, &task); err == nil {
+			printTasks(stdout, []taskInfo{task})
+			fmt.Fprintf(stdout, "\nRuns %s.\n", task.Description)
+			if task.Skipped > 0 {
+				fmt.Fprintf(stdout, "Skipped %d runs on calendar days.\n", task.Skipped)
+			}
+		}
+	case "run":
+		if err = client.do("POST", "/tasks/"+name+"/run", nil); err == nil {
+			fmt.Fprintf(stdout, "Started task '%s'\n", name)
+		}
+	case "pause", "resume":
+		if err = client.do("POST", "/tasks/"+name+"/"+command, nil); err == nil {
+			fmt.Fprintf(stdout, "Task '%s' %sd\n", name, command)
+		}
+	case "logs":
+		var history []runRecord
+		if err = client.do("GET", "/tasks/"+name+"/history?limit=10", &history); err == nil {
+			printLogs(stdout, history)
+		}
+	case "history":
+		var history []runRecord
+		if err = client.do("GET", "/tasks/"+name+"/history", &history); err == nil {
+			printRuns(stdout, history)
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "ctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printTasks writes a table of tasks.
+func printTasks(w io.Writer, tasks []taskInfo) {
+	tw := newTable(w)
+	fmt.Fprintln(tw, "NAME\tSCHEDULE\tSTATUS\tNEXT RUN\tLAST RESULT")
+	for _, task := range tasks {
+		status := "active"
+		if task.Paused {
+			status = "paused"
+		}
+		if task.Running > 0 {
+			status += ", running"
+		}
+		next := "-"
+		if task.NextRun != nil {
+			next = task.NextRun.Format(time.RFC3339)
+		}
+		last := "-"
+		if task.LastRun != nil {
+			last = fmt.Sprintf("exit %d at %s", task.LastRun.ExitCode, task.LastRun.End.Format(time.RFC3339))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", task.Name, task.Schedule, status, next, last)
+	}
+	tw.Flush()
+}
+
+// newTable returns a tabwriter for aligned, space-separated columns.
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// printLogs writes the output of runs, oldest first.
+func printLogs(w io.Writer, history []runRecord) {
+	for i := len(history) - 1; i >= 0; i-- {
+		run := history[i]
+		fmt.Fprintf(w, "==> %s (%s, exit %d, %v)\n", run.Start.Format(time.RFC3339), run.Trigger, run.ExitCode, run.Duration().Round(time.Millisecond))
+		fmt.Fprint(w, run.Output)
+		if len(run.Output) > 0 && run.Output[len(run.Output)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+	}
+}
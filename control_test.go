@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestControlSocket starts a control socket for a test registry
+func startTestControlSocket(t *testing.T) (string, *taskRegistry) {
+	t.Helper()
+	reg := newTestRegistry()
+	path := filepath.Join(t.TempDir(), "gron.sock")
+
+	server, err := startControlSocket(path, reg)
+	if err != nil {
+		t.Fatalf("failed to start control socket: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return path, reg
+}
+
+// TestControlSocketPermissions verifies the socket is only accessible to its owner
+func TestControlSocketPermissions(t *testing.T) {
+	path, reg := startTestControlSocket(t)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected permissions 0600, got %o", perm)
+	}
+
+	// A second instance must not take over a socket in use
+	if _, err := startControlSocket(path, reg); err == nil {
+		t.Error("expected error when socket is already in use")
+	}
+}
+
+// TestCtlCommands verifies the ctl subcommands against a live control socket
+func TestCtlCommands(t *testing.T) {
+	mockRunner := &MockCommandRunner{ReturnOutput: []byte("backup finished\n")}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+
+	useTestHistory(t)
+	path, reg := startTestControlSocket(t)
+
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := ctl(append([]string{"-socket", path}, args...), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, out, _ := run("list")
+	if code != 0 || !strings.Contains(out, "backup") || !strings.Contains(out, "poll") {
+		t.Errorf("unexpected list output (exit %d): %s", code, out)
+	}
+
+	if code, out, _ = run("pause", "backup"); code != 0 || !strings.Contains(out, "paused") {
+		t.Errorf("unexpected pause output (exit %d): %s", code, out)
+	}
+	if !reg.get("backup").state.isPaused() {
+		t.Error("expected task to be paused")
+	}
+
+	if code, out, _ = run("show", "backup"); code != 0 || !strings.Contains(out, "paused") {
+		t.Errorf("unexpected show output (exit %d): %s", code, out)
+	}
+
+	if code, _, _ = run("resume", "backup"); code != 0 || reg.get("backup").state.isPaused() {
+		t.Errorf("expected task to be resumed (exit %d)", code)
+	}
+
+	if code, _, _ = run("run", "backup"); code != 0 {
+		t.Errorf("expected run to succeed, got exit %d", code)
+	}
+
+	// Wait for the manual run to finish
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, lastRun := reg.get("backup").state.snapshot(); lastRun != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code, out, _ = run("logs", "backup"); code != 0 || !strings.Contains(out, "backup finished") {
+		t.Errorf("unexpected logs output (exit %d): %s", code, out)
+	}
+
+	if code, _, errOut := run("run", "missing"); code != 1 || !strings.Contains(errOut, "task not found") {
+		t.Errorf("expected error for unknown task (exit %d): %s", code, errOut)
+	}
+
+	if code, _, _ = run("bogus", "backup"); code != 2 {
+		t.Errorf("expected usage error for unknown command, got exit %d", code)
+	}
+}
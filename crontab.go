@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// systemCrontab is the system crontab, which has a user column like the
+// files in cron.d directories.
+const systemCrontab = "/etc/crontab"
+
+// crontabAssignment matches "NAME = value" environment lines of a crontab.
+var crontabAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// crontabNameComment matches a "# name: <name>" comment naming the entry
+// below it.
+var crontabNameComment = regexp.MustCompile(`^#\s*name:\s*([a-z0-9_]+)\s*$`)
+
+// cronDirFilePattern matches the names of files read from a cron.d directory.
+// Like cron, files with dots or other characters (editor backups, package
+// manager leftovers) are ignored.
+var cronDirFilePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// readCrontabs reads crontab files and cron.d-style directories. Files in a
+// directory and the system crontab have a user column; other files are user
+// crontabs without it. It returns the tasks keyed by name, and the errors of
+// invalid lines, which are skipped. Unreadable paths are returned as error.
+// Schedules may use the given aliases.
+func readCrontabs(paths []string, aliases map[string]string) (map[string]taskConfig, []error, error) {
+	tasks := make(map[string]taskConfig)
+	var errs []error
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var files []string
+		system := filepath.Clean(path) == systemCrontab
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, entry := range entries {
+				if entry.IsDir() {
+					continue
+				}
+				if !cronDirFilePattern.MatchString(entry.Name()) {
+					log.Printf("Ignoring crontab file %s", filepath.Join(path, entry.Name()))
+					continue
+				}
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+			system = true
+		} else {
+			files = []string{path}
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			fileTasks, fileErrs := parseCrontab(file, string(data), system, aliases)
+			errs = append(errs, fileErrs...)
+			for name, task := range fileTasks {
+				if _, ok := tasks[name]; ok {
+					errs = append(errs, fmt.Errorf("%s: duplicate task name '%s'", file, name))
+					continue
+				}
+				tasks[name] = task
+			}
+		}
+	}
+	return tasks, errs, nil
+}
+
+// parseCrontab parses the content of a crontab file. Each entry becomes a
+// task named after the file and either a "# name: <name>" comment right
+// above it or a hash of the entry, e.g. "crontab_backup" or
+// "crontab_1a2b3c4d", so that editing other lines keeps its name.
+// Environment assignments apply to the entries below them: SHELL selects the
+// shell, CRON_TZ the time zone of the schedules and MAILTO the mail
+// recipients; all other variables, and SHELL, are passed to the commands.
+// In system crontabs the schedule is followed by the user to run as.
+func parseCrontab(path, content string, system bool, aliases map[string]string) (map[string]taskConfig, []error) {
+	tasks := make(map[string]taskConfig)
+	var errs []error
+
+	env := make(map[string]string)
+	var shell, timezone string
+	var mailTo []string
+	hasMailTo := false
+	base := crontabTaskName(filepath.Base(path))
+	explicitName := ""
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if m := crontabNameComment.FindStringSubmatch(line); m != nil {
+			explicitName = m[1]
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			explicitName = ""
+			continue
+		}
+		position := path + ":" + strconv.Itoa(i+1)
+		name := base + "_" + explicitName
+		if explicitName == "" {
+			name = crontabEntryName(base, line)
+		}
+		explicitName = ""
+
+		if m := crontabAssignment.FindStringSubmatch(line); m != nil {
+			name, value := m[1], unquoteCrontabValue(strings.TrimSpace(m[2]))
+			switch name {
+			case "CRON_TZ":
+				timezone = value
+			case "MAILTO":
+				mailTo, hasMailTo = splitRecipients(value), true
+			default:
+				if name == "SHELL" {
+					shell = value
+				}
+				env[name] = value
+			}
+			continue
+		}
+
+		scheduleFields := scheduleFieldCount(strings.Fields(line))
+		columns := scheduleFields
+		if system {
+			columns++
+		}
+
+		fields, command := splitColumns(line, columns)
+		if len(fields) < columns || command == "" {
+			errs = append(errs, fmt.Errorf("%s: invalid crontab entry: %s", position, line))
+			continue
+		}
+		spec := strings.Join(fields[:scheduleFields], " ")
+		if _, err := parseSchedule(expandAlias(spec, aliases)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", position, err))
+			continue
+		}
+
+		command, stdin := splitCrontabCommand(command)
+		task := taskConfig{Schedule: spec, Command: command, Stdin: stdin, position: position}
+		// Like Vixie cron, a day matching either day field is enough when
+		// neither of them starts with "*".
+		task.dayMatchAny = !strings.HasPrefix(spec, "@") &&
+			!strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*")
+		task.Shell = shell
+		task.Timezone = timezone
+		task.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			task.Env[k] = v
+		}
+		if system {
+			task.User = fields[scheduleFields]
+		}
+		if hasMailTo {
+			task.Mail = &mailConfig{To: mailTo}
+		}
+		if _, ok := tasks[name]; ok {
+			errs = append(errs, fmt.Errorf("%s: duplicate task name '%s', add a '# name:' comment above the entry", position, name))
+			continue
+		}
+		tasks[name] = task
+	}
+	return tasks, errs
+}
+
+// crontabEntryName names a crontab entry after its file and a hash of the
+// entry, with its spacing collapsed.
+func crontabEntryName(base, line string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(strings.Fields(line), " ")))
+	return fmt.Sprintf("%s_%08x", base, h.Sum32())
+}
+
+// crontabTaskName turns a file name into a valid task name prefix.
+func crontabTaskName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// unquoteCrontabValue removes matching single or double quotes around a value.
+func unquoteCrontabValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// splitRecipients splits a comma-separated list of mail recipients. An empty
+// list disables mail.
+func splitRecipients(value string) []string {
+	to := []string{}
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+// splitColumns splits the first n whitespace-separated columns off a line and
+// returns them with the rest of the line, whose spacing is preserved.
+func splitColumns(line string, n int) ([]string, string) {
+	var fields []string
+	rest := strings.TrimLeft(line, " \t")
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return fields, rest
+}
+
+// splitCrontabCommand applies the "%" semantics of cron: the first unescaped
+// "%" ends the command and the rest is sent to its standard input, with every
+// further unescaped "%" replaced by a newline. "\%" is a literal "%".
+func splitCrontabCommand(command string) (string, string) {
+	var cmd, stdin strings.Builder
+	out := &cmd
+	hasStdin := false
+	for i := 0; i < len(command); i++ {
+		switch {
+		case command[i] == '\\' && i+1 < len(command) && command[i+1] == '%':
+			out.WriteByte('%')
+			i++
+		case command[i] == '%' && !hasStdin:
+			out, hasStdin = &stdin, true
+		case command[i] == '%':
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(command[i])
+		}
+	}
+	if hasStdin {
+		stdin.WriteByte('\n')
+	}
+	return cmd.String(), stdin.String()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCrontab = `# m h dom mon dow user command
+SHELL=/bin/sh
+PATH = "/usr/local/bin:/usr/bin:/bin"
+
+17 * * * * root cd / && run-parts --report /etc/cron.hourly
+MAILTO=ops@example.com
+CRON_TZ=Europe/Berlin
+# name: report
+25 6 * * * www-data /usr/bin/report.sh
+@daily nobody mail -s "disk usage" root%df -h%du -sh /var
+MAILTO=""
+*/5 * * * * root echo 100\% done > /tmp/status
+* * * * *
+`
+
+// TestParseCrontab verifies parsing of a system crontab
+func TestParseCrontab(t *testing.T) {
+	tasks, errs := parseCrontab("/etc/crontab", testCrontab, true, nil)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "/etc/crontab:13:") {
+		t.Errorf("expected an error for line 13, got %v", errs)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("expected 4 tasks, got %d: %v", len(tasks), sortedTaskNames(tasks))
+	}
+
+	hourly := tasks[crontabEntryName("crontab", "17 * * * * root cd / && run-parts --report /etc/cron.hourly")]
+	if hourly.Schedule != "17 * * * *" || hourly.User != "root" || hourly.Command != "cd / && run-parts --report /etc/cron.hourly" {
+		t.Errorf("unexpected hourly task: %+v", hourly)
+	}
+	if hourly.Shell != "/bin/sh" || hourly.Env["PATH"] != "/usr/local/bin:/usr/bin:/bin" || hourly.Env["SHELL"] != "/bin/sh" {
+		t.Errorf("unexpected hourly environment: %q, %v", hourly.Shell, hourly.Env)
+	}
+	if hourly.Mail != nil || hourly.Timezone != "" {
+		t.Errorf("expected assignments to apply only below them, got %+v", hourly)
+	}
+
+	report := tasks["crontab_report"]
+	if report.User != "www-data" || report.Timezone != "Europe/Berlin" || report.Mail == nil || report.Mail.To[0] != "ops@example.com" {
+		t.Errorf("unexpected report task: %+v", report)
+	}
+
+	usage := tasks[crontabEntryName("crontab", `@daily nobody mail -s "disk usage" root%df -h%du -sh /var`)]
+	if usage.Schedule != "@daily" || usage.Command != `mail -s "disk usage" root` || usage.Stdin != "df -h\ndu -sh /var\n" {
+		t.Errorf("unexpected stdin task: %q %q", usage.Command, usage.Stdin)
+	}
+
+	status := tasks[crontabEntryName("crontab", `*/5 * * * * root echo 100\% done > /tmp/status`)]
+	if status.Command != "echo 100% done > /tmp/status" || status.Stdin != "" {
+		t.Errorf("unexpected escaped task: %q %q", status.Command, status.Stdin)
+	}
+	if status.Mail == nil || len(status.Mail.To) != 0 {
+		t.Errorf("expected empty MAILTO to disable mail, got %+v", status.Mail)
+	}
+}
+
+// TestParseUserCrontab verifies user crontabs have no user column
+func TestParseUserCrontab(t *testing.T) {
+	tasks, errs := parseCrontab("/var/spool/cron/crontabs/root", "0 3 * * * root-cleanup.sh --all\n@every 10m poll.sh\n", false, nil)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if task := tasks[crontabEntryName("root", "0 3 * * * root-cleanup.sh --all")]; task.Command != "root-cleanup.sh --all" || task.User != "" {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if task := tasks[crontabEntryName("root", "@every 10m poll.sh")]; task.Schedule != "@every 10m" || task.Command != "poll.sh" {
+		t.Errorf("unexpected task: %+v", task)
+	}
+}
+
+// TestCrontabDayFields verifies names and the rule that either restricted day field matches
+func TestCrontabDayFields(t *testing.T) {
+	content := "# name: weekdays\n0 9 * jan-mar mon-fri weekdays.sh\n" +
+		"# name: either\n0 9 1,15 * fri either.sh\n" +
+		"# name: stepped\n0 9 */2 * fri stepped.sh\n"
+	path := filepath.Join(t.TempDir(), "jobs")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfiguration("", []string{path}, nil)
+	if err != nil || len(config.errors) != 0 {
+		t.Fatalf("unexpected errors: %v %v", err, config.errors)
+	}
+	tasks := make(map[string]*CronSchedule)
+	for _, task := range config.tasks {
+		tasks[task.name] = task
+	}
+
+	// 2026-01-15 is a Thursday, 2026-01-16 a Friday and 2026-01-17 a Saturday.
+	tests := []struct {
+		task     string
+		day      int
+		expected bool
+	}{
+		{"jobs_weekdays", 16, true},
+		{"jobs_weekdays", 17, false},
+		{"jobs_either", 15, true},
+		{"jobs_either", 16, true},
+		{"jobs_either", 17, false},
+		// A day field starting with "*" still has to match, like in cron.
+		{"jobs_stepped", 16, false},
+		{"jobs_stepped", 23, true},
+	}
+	for _, tt := range tests {
+		task := tasks[tt.task]
+		if task == nil {
+			t.Fatalf("missing task %s", tt.task)
+		}
+		task.location = time.UTC
+		if got := task.shouldRun(time.Date(2026, 1, tt.day, 9, 0, 0, 0, time.UTC)); got != tt.expected {
+			t.Errorf("%s on January %d: expected %v, got %v", tt.task, tt.day, tt.expected, got)
+		}
+	}
+	if got := tasks["jobs_either"].describe(); got != "at 09:00, on days 1 and 15 of the month or on Friday" {
+		t.Errorf("unexpected description: %s", got)
+	}
+	if got := tasks["jobs_either"].next(time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)); got.Day() != 9 {
+		t.Errorf("expected the next run on Friday January 9, got %v", got)
+	}
+}
+
+// TestSplitCrontabCommand verifies the percent sign semantics
+func TestSplitCrontabCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		cmd     string
+		stdin   string
+	}{
+		{"plain", "echo hello", "echo hello", ""},
+		{"stdin", "cat%hello", "cat", "hello\n"},
+		{"multiline", "cat%a%b", "cat", "a\nb\n"},
+		{"escaped", `date +\%Y-\%m-\%d`, "date +%Y-%m-%d", ""},
+		{"escaped_stdin", `cat%50\% off`, "cat", "50% off\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, stdin := splitCrontabCommand(tt.command)
+			if cmd != tt.cmd || stdin != tt.stdin {
+				t.Errorf("expected %q, %q, got %q, %q", tt.cmd, tt.stdin, cmd, stdin)
+			}
+		})
+	}
+}
+
+// TestReadCrontabs verifies loading of cron.d directories through the configuration
+func TestReadCrontabs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"backup-db":     "MAILTO=dba@example.com\n0 2 * * * postgres pg_dumpall > /backup/all.sql\n",
+		"logrotate":     "CRON_TZ=America/New_York\n0 0 * * * root logrotate /etc/logrotate.conf\n",
+		"old.dpkg-dist": "* * * * * root echo ignored\n",
+		"notes~":        "* * * * * root echo ignored\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	config, err := loadConfiguration("", []string{dir}, []string{"TASK_PING=@hourly ping.sh"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 0 {
+		t.Errorf("unexpected errors: %v", config.errors)
+	}
+	if len(config.tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(config.tasks))
+	}
+
+	backup, logrotate := config.tasks[0], config.tasks[1]
+	if backup.name != crontabEntryName("backup_db", "0 2 * * * postgres pg_dumpall > /backup/all.sql") || backup.exec.user != "postgres" {
+		t.Errorf("unexpected backup task: %s as %q", backup.name, backup.exec.user)
+	}
+	if backup.mail != nil || config.mailer != nil {
+		t.Error("expected MAILTO to be ignored without an SMTP server")
+	}
+	if logrotate.location == nil || logrotate.location.String() != "America/New_York" {
+		t.Fatalf("unexpected location: %v", logrotate.location)
+	}
+	// Midnight in New York is 05:00 UTC in winter
+	if !logrotate.shouldRun(time.Date(2025, 1, 15, 5, 0, 0, 0, time.UTC)) || logrotate.shouldRun(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected the schedule to be evaluated in its time zone")
+	}
+
+	if _, err := loadConfiguration("", []string{filepath.Join(dir, "missing")}, nil); err == nil {
+		t.Error("expected error for a missing crontab")
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListedTimes is the number of times of day up to which a schedule is
+// described by listing them, e.g. "at 09:00 and 17:30".
+const maxListedTimes = 6
+
+// describe returns an English description of the schedule, such as "every 2
+// hours at minute 0, Monday through Friday".
+func (s *CronSchedule) describe() string {
+	if s.isReboot {
+		return "once when gron starts"
+	}
+	if s.isAt {
+		return "once at " + s.at.Format(time.RFC3339)
+	}
+	if s.isEvery && s.aligned {
+		return "every " + describeInterval(s.interval) + ", " + s.describeAlignment()
+	}
+	if s.isEvery {
+		return "every " + describeInterval(s.interval)
+	}
+
+	when, atTimes := s.describeTime()
+	var days []string
+	if phrase := describeDaysOfMonth(s.daysOfMonth); phrase != "" {
+		days = append(days, phrase)
+	}
+	if s.lastDays != nil {
+		days = append(days, describeLastDays(s.lastDays))
+	}
+	if phrase := describeDaysOfWeek(s.normalizedDaysOfWeek()); phrase != "" {
+		if s.dayMatchAny && len(days) > 0 {
+			// Either day field matching is enough.
+			phrase = days[len(days)-1] + " or " + phrase
+			days = days[:len(days)-1]
+		}
+		days = append(days, phrase)
+	}
+	if phrase := describeMonths(s.months); phrase != "" {
+		days = append(days, phrase)
+	}
+	if phrase := describeYears(s.years); phrase != "" {
+		days = append(days, phrase)
+	}
+	if len(days) == 0 && atTimes {
+		days = append(days, "every day")
+	}
+	return strings.Join(append([]string{when}, days...), ", ")
+}
+
+// describeTime describes the minutes and hours of the schedule. It reports
+// whether the description is a list of times of day, which reads better
+// followed by the days even if the schedule runs every day.
+func (s *CronSchedule) describeTime() (string, bool) {
+	minuteLimits, hourLimits := cronFields[0], cronFields[1]
+	allMinutes := len(s.minutes) == minuteLimits.max-minuteLimits.min+1
+	allHours := len(s.hours) == hourLimits.max-hourLimits.min+1
+	minuteStep := evenStep(s.minutes, minuteLimits)
+	hourStep := fieldStep(s.hours, hourLimits)
+
+	switch {
+	case allMinutes && allHours:
+		return "every minute", false
+	case allHours && minuteStep > 1:
+		return fmt.Sprintf("every %d minutes", minuteStep), false
+	case allHours && len(s.minutes) == 1:
+		return fmt.Sprintf("every hour at minute %d", s.minutes[0]), false
+	case allHours:
+		return describeMinutes(s.minutes) + " past every hour", false
+	case evenStep(s.hours, hourLimits) > 1 && len(s.minutes) == 1:
+		return fmt.Sprintf("every %d hours at minute %d", hourStep, s.minutes[0]), false
+	case hourStep > 1:
+		hours := listValues(s.hours, "%02d", len(s.hours))
+		return fmt.Sprintf("%s during every %s hour (%s)", describeMinutes(s.minutes), ordinal(hourStep), hours), false
+	case !allMinutes && minuteStep <= 1 && len(s.minutes)*len(s.hours) <= maxListedTimes:
+		var times []string
+		for _, h := range s.hours {
+			for _, m := range s.minutes {
+				times = append(times, fmt.Sprintf("%02d:%02d", h, m))
+			}
+		}
+		return "at " + joinAnd(times), true
+	}
+
+	minutes := describeMinutes(s.minutes)
+	if s.hours[len(s.hours)-1]-s.hours[0] == len(s.hours)-1 {
+		return fmt.Sprintf("%s, between %02d:00 and %02d:59", minutes, s.hours[0], s.hours[len(s.hours)-1]), false
+	}
+	return fmt.Sprintf("%s, during hours %s", minutes, joinAnd(describeValues(s.hours, strconv.Itoa))), false
+}
+
+// describeMinutes describes the minutes of a schedule on their own.
+func describeMinutes(minutes []int) string {
+	limits := cronFields[0]
+	switch step := evenStep(minutes, limits); {
+	case len(minutes) == limits.max-limits.min+1:
+		return "every minute"
+	case step > 1:
+		return fmt.Sprintf("every %d minutes", step)
+	case len(minutes) == 1:
+		return fmt.Sprintf("at minute %d", minutes[0])
+	}
+	return "at minutes " + joinAnd(describeValues(minutes, strconv.Itoa))
+}
+
+// describeDaysOfMonth describes the days of the month, or returns an empty
+// string if the schedule runs on all of them.
+func describeDaysOfMonth(days []int) string {
+	limits := cronFields[2]
+	switch step := fieldStep(days, limits); {
+	case len(days) == limits.max-limits.min+1:
+		return ""
+	case step > 1:
+		return fmt.Sprintf("on every %s day of the month (%s)", ordinal(step), listValues(days, "%d", 3))
+	case len(days) == 1:
+		return fmt.Sprintf("on day %d of the month", days[0])
+	}
+	return "on days " + joinAnd(describeValues(days, strconv.Itoa)) + " of the month"
+}
+
+// describeDaysOfWeek describes the days of the week, with Sunday as 0, or
+// returns an empty string if the schedule runs on all of them.
+func describeDaysOfWeek(days []int) string {
+	if len(days) == 7 {
+		return ""
+	}
+	items := describeValues(days, func(d int) string { return time.Weekday(d).String() })
+	if len(items) == 1 && strings.Contains(items[0], " through ") {
+		return items[0]
+	}
+	return "on " + joinAnd(items)
+}
+
+// describeMonths describes the months, or returns an empty string if the
+// schedule runs in all of them.
+func describeMonths(months []int) string {
+	limits := cronFields[3]
+	switch step := evenStep(months, limits); {
+	case len(months) == limits.max-limits.min+1:
+		return ""
+	case step > 1:
+		return fmt.Sprintf("every %d months", step)
+	}
+	items := describeValues(months, func(m int) string { return time.Month(m).String() })
+	if len(items) == 1 && strings.Contains(items[0], " through ") {
+		return items[0]
+	}
+	return "in " + joinAnd(items)
+}
+
+// evenStep returns the step of the values like fieldStep, but only if it
+// divides the range of the field, so that the values are also a step apart
+// across the end of the range. "*/7" of minutes has a gap of 4 from 56 to 0.
+func evenStep(values []int, limits CronField) int {
+	step := fieldStep(values, limits)
+	if step == 0 || (limits.max-limits.min+1)%step != 0 {
+		return 0
+	}
+	return step
+}
+
+// describeValues names sorted values, turning runs of three or more
+// consecutive values into "a through b".
+func describeValues(values []int, name func(int) string) []string {
+	var items []string
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			items = append(items, name(values[i])+" through "+name(values[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				items = append(items, name(values[k]))
+			}
+		}
+		i = j + 1
+	}
+	return items
+}
+
+// listValues formats values as a comma-separated list, ending it with an
+// ellipsis after the first limit values: "1, 3, 5, …".
+func listValues(values []int, format string, limit int) string {
+	items := make([]string, 0, limit+1)
+	for i, v := range values {
+		if i == limit {
+			items = append(items, "…")
+			break
+		}
+		items = append(items, fmt.Sprintf(format, v))
+	}
+	return strings.Join(items, ", ")
+}
+
+// ordinal writes n as an English ordinal number, e.g. "2nd" or "11th".
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// joinAnd joins items as an English list: "a", "a and b", "a, b and c".
+func joinAnd(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// describeInterval writes a duration in words, e.g. "2 hours 30 minutes" or
+// "day" for exactly one day.
+func describeInterval(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{7 * 24 * time.Hour, "week"},
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+
+	if d <= 0 || d%time.Second != 0 {
+		return formatInterval(d)
+	}
+
+	var parts []string
+	for _, unit := range units {
+		n := d / unit.size
+		if n == 0 {
+			continue
+		}
+		d -= n * unit.size
+		if n == 1 {
+			parts = append(parts, "1 "+unit.name)
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
+		}
+	}
+	if len(parts) == 1 && strings.HasPrefix(parts[0], "1 ") {
+		return strings.TrimPrefix(parts[0], "1 ")
+	}
+	return strings.Join(parts, " ")
+}
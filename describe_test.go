@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// TestDescribe verifies the English descriptions of schedules
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"* * * * *", "every minute"},
+		{"*/15 * * * *", "every 15 minutes"},
+		{"*/7 * * * *", "at minutes 0, 7, 14, 21, 28, 35, 42, 49 and 56 past every hour"},
+		{"0 * * * *", "every hour at minute 0"},
+		{"5,35 * * * *", "at minutes 5 and 35 past every hour"},
+		{"0 */2 * * 1-5", "every 2 hours at minute 0, Monday through Friday"},
+		{"0 */5 * * *", "at minute 0 during every 5th hour (00, 05, 10, 15, 20)"},
+		{"30 */9 * * *", "at minute 30 during every 9th hour (00, 09, 18)"},
+		{"*/10 */6 * * *", "every 10 minutes during every 6th hour (00, 06, 12, 18)"},
+		{"5,35 */8 * * *", "at minutes 5 and 35 during every 8th hour (00, 08, 16)"},
+		{"@daily", "at 00:00, every day"},
+		{"@weekly", "at 00:00, on Sunday"},
+		{"@monthly", "at 00:00, on day 1 of the month"},
+		{"@yearly", "at 00:00, on day 1 of the month, in January"},
+		{"30 9,17 * * *", "at 09:30 and 17:30, every day"},
+		{"0 12 * * 0,7", "at 12:00, on Sunday"},
+		{"0 8 * * 1,3,5", "at 08:00, on Monday, Wednesday and Friday"},
+		{"*/15 9-17 * * 1-5", "every 15 minutes, between 09:00 and 17:59, Monday through Friday"},
+		{"0 1-23/2 * * *", "at minute 0, during hours 1, 3, 5, 7, 9, 11, 13, 15, 17, 19, 21 and 23"},
+		{"0 0 1,15 * *", "at 00:00, on days 1 and 15 of the month"},
+		{"0 0 */2 * *", "at 00:00, on every 2nd day of the month (1, 3, 5, …)"},
+		{"0 0 */15 * *", "at 00:00, on every 15th day of the month (1, 16, 31)"},
+		{"0 0 1-7 * 1", "at 00:00, on days 1 through 7 of the month, on Monday"},
+		{"0 0 1 */3 *", "at 00:00, on day 1 of the month, every 3 months"},
+		{"0 0 * */5 *", "at 00:00, in January, June and November"},
+		{"0 0 * 6-8 *", "at 00:00, June through August"},
+		{"@every 1h", "every hour"},
+		{"@every 90m", "every 1 hour 30 minutes"},
+		{"@every 2d", "every 2 days"},
+		{"@every 2w", "every 2 weeks"},
+		{"@every 1w2d", "every 1 week 2 days"},
+		{"@every 45s", "every 45 seconds"},
+		{"@every 1500ms", "every 1.5s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.describe(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
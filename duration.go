@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minEveryInterval is the shortest interval accepted for @every schedules.
+const minEveryInterval = time.Second
+
+// durationUnits are the units of extended durations, longest names first so
+// that "ms" is not read as minutes.
+var durationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"ns", time.Nanosecond},
+	{"us", time.Microsecond},
+	{"µs", time.Microsecond},
+	{"ms", time.Millisecond},
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// parseExtendedDuration parses a duration like time.ParseDuration, with the
+// additional units "d" for days and "w" for weeks. Units can be mixed and
+// numbers can have fractions, e.g. "1w2d", "1d12h" or "1.5d". Signs are not
+// accepted.
+func parseExtendedDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total float64
+	rest := s
+	for rest != "" {
+		end := 0
+		for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.') {
+			end++
+		}
+		if end == 0 {
+			return 0, fmt.Errorf("invalid duration '%s': expected a number at '%s'", s, rest)
+		}
+		value, err := strconv.ParseFloat(rest[:end], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': invalid number '%s'", s, rest[:end])
+		}
+		rest = rest[end:]
+
+		unit := -1
+		for i, u := range durationUnits {
+			if strings.HasPrefix(rest, u.name) {
+				unit = i
+				break
+			}
+		}
+		if unit < 0 {
+			if rest == "" {
+				return 0, fmt.Errorf("invalid duration '%s': missing unit after '%s'", s, strconv.FormatFloat(value, 'f', -1, 64))
+			}
+			return 0, fmt.Errorf("invalid duration '%s': unknown unit at '%s', expected w, d, h, m, s, ms, us or ns", s, rest)
+		}
+		total += value * float64(durationUnits[unit].size)
+		rest = rest[len(durationUnits[unit].name):]
+	}
+
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration '%s': too long", s)
+	}
+	return time.Duration(math.Round(total)), nil
+}
+
+// parseEveryInterval parses the interval of an @every schedule, which must
+// be at least minEveryInterval.
+func parseEveryInterval(s string) (time.Duration, error) {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return 0, fmt.Errorf("invalid interval '%s': must be positive", s)
+	}
+	d, err := parseExtendedDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < minEveryInterval {
+		return 0, fmt.Errorf("invalid interval '%s': must be at least %v", s, minEveryInterval)
+	}
+	return d, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseExtendedDuration verifies durations with days, weeks and fractions
+func TestParseExtendedDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		err      string
+	}{
+		{"90s", 90 * time.Second, ""},
+		{"1d12h", 36 * time.Hour, ""},
+		{"1.5d", 36 * time.Hour, ""},
+		{"2w", 14 * 24 * time.Hour, ""},
+		{"1w1d1h1m1s", 8*24*time.Hour + time.Hour + time.Minute + time.Second, ""},
+		{"250ms", 250 * time.Millisecond, ""},
+		{"0s", 0, ""},
+		{"", 0, "empty duration"},
+		{"10", 0, "invalid duration '10': missing unit after '10'"},
+		{"1y", 0, "invalid duration '1y': unknown unit at 'y', expected w, d, h, m, s, ms, us or ns"},
+		{"h", 0, "invalid duration 'h': expected a number at 'h'"},
+		{"1..5h", 0, "invalid duration '1..5h': invalid number '1..5'"},
+		{"-1h", 0, "invalid duration '-1h': expected a number at '-1h'"},
+		{"100000000w", 0, "invalid duration '100000000w': too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := parseExtendedDuration(tt.input)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil || d != tt.expected {
+				t.Errorf("expected %v, got %v, %v", tt.expected, d, err)
+			}
+		})
+	}
+}
+
+// TestParseEveryInterval verifies the minimum and sign of @every intervals
+func TestParseEveryInterval(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"1s", ""},
+		{"0s", "invalid interval '0s': must be at least 1s"},
+		{"999ms", "invalid interval '999ms': must be at least 1s"},
+		{"-1h", "invalid interval '-1h': must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseEveryInterval(tt.input)
+			if (tt.err == "" && err != nil) || (tt.err != "" && (err == nil || err.Error() != tt.err)) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// commandWaitDelay bounds how long a timed-out command may keep its output
+// pipes open after it was killed.
+const commandWaitDelay = 5 * time.Second
+
+// commandOptions control how a task command is executed.
+type commandOptions struct {
+	dir     string        // Working directory; the current one if empty.
+	env     []string      // Extra "KEY=value" entries added to the environment.
+	user    string        // User name or ID to run as; the current user if empty.
+	timeout time.Duration // Kill the command after this long; no limit if zero.
+	shell   string        // Shell running the command; bash or sh if empty.
+	stdin   string        // Standard input of the command.
+}
+
+// OptionsCommandRunner is a CommandRunner that supports per-task execution
+// options. Runners that do not implement it run commands without options.
+type OptionsCommandRunner interface {
+	RunWithOptions(opts commandOptions, command string, args ...string) ([]byte, error)
+}
+
+// RunWithOptions runs a command with the given options and returns its output.
+// The command runs in its own process group so that a timeout kills every
+// process it started.
+func (r *RealCommandRunner) RunWithOptions(opts commandOptions, command string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = opts.dir
+	if opts.stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.stdin)
+	}
+	if len(opts.env) > 0 {
+		cmd.Env = append(os.Environ(), opts.env...)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = commandWaitDelay
+
+	if opts.user != "" {
+		credential, err := lookupCredential(opts.user)
+		if err != nil {
+			return nil, err
+		}
+		// Switching to the current user needs no privileges, so skip it.
+		if credential.Uid != uint32(os.Getuid()) || credential.Gid != uint32(os.Getgid()) {
+			cmd.SysProcAttr.Credential = credential
+		}
+	}
+
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %v: %w", opts.timeout, err)
+	}
+	return output, err
+}
+
+// lookupCredential resolves a user name or numeric ID to the credential used
+// to run a command as that user.
+func lookupCredential(name string) (*syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		if u, err = user.LookupId(name); err != nil {
+			return nil, fmt.Errorf("unknown user '%s'", name)
+		}
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid for user '%s': %v", name, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid for user '%s': %v", name, err)
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os/user"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunWithOptions verifies the working directory, environment and timeout of commands
+func TestRunWithOptions(t *testing.T) {
+	runner := &RealCommandRunner{}
+	dir := t.TempDir()
+
+	output, err := runner.RunWithOptions(commandOptions{dir: dir, env: []string{"GRON_TEST=hello"}}, "/bin/sh", "-c", "pwd; echo $GRON_TEST")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Fields(string(output)); len(got) != 2 || got[0] != dir || got[1] != "hello" {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	output, err = runner.RunWithOptions(commandOptions{stdin: "from stdin\n"}, "/bin/sh", "-c", "cat")
+	if err != nil || string(output) != "from stdin\n" {
+		t.Errorf("expected standard input to be passed, got %q, %v", output, err)
+	}
+
+	// The timeout must kill the whole process group, including children
+	start := time.Now()
+	_, err = runner.RunWithOptions(commandOptions{timeout: 100 * time.Millisecond}, "/bin/sh", "-c", "sleep 10 & sleep 10")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the command to be killed, took %v", elapsed)
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skipf("cannot determine current user: %v", err)
+	}
+	if _, err := runner.RunWithOptions(commandOptions{user: current.Uid}, "/bin/sh", "-c", "true"); err != nil {
+		t.Errorf("expected to run as the current user, got %v", err)
+	}
+	if _, err := runner.RunWithOptions(commandOptions{user: "no-such-user-gron"}, "/bin/sh", "-c", "true"); err == nil {
+		t.Error("expected error for an unknown user")
+	}
+}
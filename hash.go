@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// maxHashedDayOfMonth is the last day of the month "H" resolves to, like in
+// Jenkins, so that hashed days exist in every month.
+const maxHashedDayOfMonth = 28
+
+// fieldSeed derives the seed of the "H" tokens of a cron field from the
+// task name, so that the fields of a task and tasks of different names
+// resolve to different values.
+func fieldSeed(name string, field int) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", name, field)
+	return h.Sum32()
+}
+
+// parseHashPart resolves a Jenkins-style hashed field element: "H" is a
+// single value within the limits of the field, "H(a-b)" a value within a-b,
+// and with a step "/n" the values of the range starting at a hashed offset
+// below n.
+func parseHashPart(part string, step int, hasStep bool, limits CronField, seed uint32) ([]int, error) {
+	start, end := limits.min, limits.max
+	if limits == cronFields[2] {
+		end = maxHashedDayOfMonth
+	}
+
+	if part != "H" {
+		inner, ok := strings.CutPrefix(part, "H(")
+		if inner, ok = strings.CutSuffix(inner, ")"); !ok {
+			return nil, fmt.Errorf("invalid hash '%s', expected H or H(a-b)", part)
+		}
+		lo, hi, isRange := strings.Cut(inner, "-")
+		var err error
+		if !isRange {
+			return nil, fmt.Errorf("invalid hash '%s', expected H or H(a-b)", part)
+		}
+		if start, err = strconv.Atoi(lo); err != nil {
+			return nil, fmt.Errorf("invalid value '%s'", lo)
+		}
+		if end, err = strconv.Atoi(hi); err != nil {
+			return nil, fmt.Errorf("invalid value '%s'", hi)
+		}
+		for _, v := range []int{start, end} {
+			if v < limits.min || v > limits.max {
+				return nil, fmt.Errorf("value %d out of range %d-%d", v, limits.min, limits.max)
+			}
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid range %d-%d", start, end)
+		}
+	}
+
+	if !hasStep {
+		return []int{start + int(seed%uint32(end-start+1))}, nil
+	}
+	offset := int(seed % uint32(step))
+	if start+offset > end {
+		offset = int(seed % uint32(end-start+1))
+	}
+	var result []int
+	for i := start + offset; i <= end; i += step {
+		result = append(result, i)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseHashedSchedule verifies H tokens are stable per task and within their ranges
+func TestParseHashedSchedule(t *testing.T) {
+	tests := []struct {
+		spec  string
+		check func(s *CronSchedule) bool
+	}{
+		{"H * * * *", func(s *CronSchedule) bool { return len(s.minutes) == 1 && s.minutes[0] <= 59 }},
+		{"H(0-29) H(1-5) * * *", func(s *CronSchedule) bool {
+			return len(s.minutes) == 1 && s.minutes[0] <= 29 && len(s.hours) == 1 && s.hours[0] >= 1 && s.hours[0] <= 5
+		}},
+		{"H/15 * * * *", func(s *CronSchedule) bool {
+			return len(s.minutes) == 4 && s.minutes[0] < 15 && s.minutes[1]-s.minutes[0] == 15
+		}},
+		{"0 H(8-17)/4 * * *", func(s *CronSchedule) bool {
+			return len(s.hours) >= 2 && s.hours[0] >= 8 && s.hours[0] < 12 && s.hours[len(s.hours)-1] <= 17
+		}},
+		{"0 0 H * *", func(s *CronSchedule) bool { return len(s.daysOfMonth) == 1 && s.daysOfMonth[0] <= 28 }},
+		{"0 0 * * H", func(s *CronSchedule) bool { return len(s.daysOfWeek) == 1 && s.daysOfWeek[0] <= 6 }},
+		{"H,30 * * * *", func(s *CronSchedule) bool { return len(s.minutes) >= 1 && s.minutes[len(s.minutes)-1] >= 30 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			for _, name := range []string{"backup", "cleanup", "report"} {
+				a, err := parseScheduleFor(tt.spec, name)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				b, _ := parseScheduleFor(tt.spec, name)
+				if a.normalized() != b.normalized() {
+					t.Errorf("expected %s to resolve the same for %s, got %s and %s", tt.spec, name, a.normalized(), b.normalized())
+				}
+				if !tt.check(a) {
+					t.Errorf("unexpected values for %s with %s: %s", tt.spec, name, a.normalized())
+				}
+				if a.spec != tt.spec {
+					t.Errorf("expected the spec to be kept, got %s", a.spec)
+				}
+			}
+		})
+	}
+
+	// Tasks are spread over the hour
+	minutes := make(map[int]bool)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		s, _ := parseScheduleFor("H * * * *", name)
+		minutes[s.minutes[0]] = true
+	}
+	if len(minutes) < 4 {
+		t.Errorf("expected tasks to be spread, got minutes %v", minutes)
+	}
+}
+
+// TestParseHashedScheduleErrors verifies invalid H tokens are reported
+func TestParseHashedScheduleErrors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"H(0-60) * * * *", "field 1 (minute) 'H(0-60)': value 60 out of range 0-59"},
+		{"H(5) * * * *", "field 1 (minute) 'H(5)': invalid hash 'H(5)', expected H or H(a-b)"},
+		{"H(10-5) * * * *", "field 1 (minute) 'H(10-5)': invalid range 10-5"},
+		{"Hx * * * *", "field 1 (minute) 'Hx': invalid hash 'Hx', expected H or H(a-b)"},
+		{"H/0 * * * *", "field 1 (minute) 'H/0': step must be positive, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parseScheduleFor(tt.spec, "backup")
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestScheduledDelay verifies the jitter stays below its maximum
+func TestScheduledDelay(t *testing.T) {
+	task := &CronSchedule{startDelay: time.Minute, jitter: 10 * time.Second}
+	varied := false
+	first := task.scheduledDelay()
+	for i := 0; i < 100; i++ {
+		d := task.scheduledDelay()
+		if d < time.Minute || d >= time.Minute+10*time.Second {
+			t.Fatalf("delay %v out of bounds", d)
+		}
+		varied = varied || d != first
+	}
+	if !varied {
+		t.Error("expected the jitter to vary between runs")
+	}
+
+	if d := (&CronSchedule{}).scheduledDelay(); d != 0 {
+		t.Errorf("expected no delay, got %v", d)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// heartbeatInterval is how often the scheduler loop reports that it is alive.
+const heartbeatInterval = 10 * time.Second
+
+// defaultHealthMaxAge is how old the last heartbeat may be before /healthz
+// reports the scheduler as stalled.
+const defaultHealthMaxAge = 3 * heartbeatInterval
+
+// healthState tracks scheduler liveness and the outcome of task loading.
+type healthState struct {
+	mu         sync.RWMutex
+	heartbeat  time.Time
+	loaded     bool
+	taskCount  int
+	loadErrors []string
+	maxAge     time.Duration
+}
+
+// health is the process-wide health state reported by the health endpoints.
+var health = &healthState{maxAge: defaultHealthMaxAge}
+
+// beat records a heartbeat from the scheduler loop.
+func (h *healthState) beat(t time.Time) {
+	h.mu.Lock()
+	h.heartbeat = t
+	h.mu.Unlock()
+}
+
+// setLoaded records the result of loading tasks.
+func (h *healthState) setLoaded(taskCount int, errs []error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loaded = true
+	h.taskCount = taskCount
+	h.loadErrors = nil
+	for _, err := range errs {
+		h.loadErrors = append(h.loadErrors, err.Error())
+	}
+}
+
+// live reports whether the scheduler has sent a heartbeat recently enough.
+func (h *healthState) live(now time.Time) (bool, time.Time) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.heartbeat.IsZero() {
+		return false, h.heartbeat
+	}
+	return now.Sub(h.heartbeat) <= h.maxAge, h.heartbeat
+}
+
+// ready reports whether tasks were loaded without errors.
+func (h *healthState) ready() (bool, int, []string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.loaded && len(h.loadErrors) == 0, h.taskCount, append([]string(nil), h.loadErrors...)
+}
+
+// handleHealthz reports whether the scheduler loop is alive.
+func (h *healthState) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ok, last := h.live(time.Now())
+	body := map[string]interface{}{"status": "ok"}
+	if !last.IsZero() {
+		body["last_heartbeat"] = last.Format(time.RFC3339)
+	}
+	status := http.StatusOK
+	if !ok {
+		body["status"] = "stalled"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, body)
+}
+
+// handleReadyz reports whether all tasks were loaded successfully.
+func (h *healthState) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ok, count, errs := h.ready()
+	body := map[string]interface{}{"status": "ready", "tasks": count}
+	status := http.StatusOK
+	if !ok {
+		body["status"] = "not ready"
+		if len(errs) > 0 {
+			body["errors"] = errs
+		}
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, body)
+}
+
+// writeJSON writes body as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// healthHandler returns the HTTP handler serving /healthz and /readyz.
+func healthHandler(h *healthState) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.handleHealthz)
+	mux.HandleFunc("/readyz", h.handleReadyz)
+	return mux
+}
+
+// startHealthServer starts serving the health endpoints on addr in the background.
+func startHealthServer(addr string, h *healthState) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: healthHandler(h), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Health server stopped: %v", err)
+		}
+	}()
+
+	log.Printf("Health endpoints listening on %s", listener.Addr())
+	return server, nil
+}
+
+// runHealthcheck implements the "healthcheck" subcommand. It probes the health
+// endpoints of a running gron and returns a non-zero exit code if either fails.
+func runHealthcheck(args []string) int {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	addr := fs.String("addr", os.Getenv("GRON_HEALTH_ADDR"), "address of the health endpoints (defaults to $GRON_HEALTH_ADDR)")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout for each probe")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *addr == "" {
+		fmt.Fprintln(os.Stderr, "healthcheck: no address given and GRON_HEALTH_ADDR is not set")
+		return 2
+	}
+
+	baseURL, err := probeBaseURL(*addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+		return 2
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	healthy := true
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := client.Get(baseURL + path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			healthy = false
+			continue
+		}
+		resp.Body.Close()
+		fmt.Printf("%s: %s\n", path, resp.Status)
+		if resp.StatusCode != http.StatusOK {
+			healthy = false
+		}
+	}
+
+	if !healthy {
+		return 1
+	}
+	return 0
+}
+
+// probeBaseURL turns a listen address such as ":8080" into a URL that can be
+// used to reach it from the same host.
+func probeBaseURL(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %v", addr, err)
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port), nil
+}
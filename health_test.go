@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHealthz verifies that /healthz tracks the scheduler heartbeat
+func TestHealthz(t *testing.T) {
+	tests := []struct {
+		name       string
+		heartbeat  time.Time
+		statusCode int
+	}{
+		{"no_heartbeat", time.Time{}, http.StatusServiceUnavailable},
+		{"fresh_heartbeat", time.Now(), http.StatusOK},
+		{"stale_heartbeat", time.Now().Add(-time.Hour), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &healthState{maxAge: defaultHealthMaxAge}
+			if !tt.heartbeat.IsZero() {
+				h.beat(tt.heartbeat)
+			}
+
+			rec := httptest.NewRecorder()
+			healthHandler(h).ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestReadyz verifies that /readyz reflects task loading errors
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		loaded     bool
+		errs       []error
+		statusCode int
+		body       string
+	}{
+		{"not_loaded", false, nil, http.StatusServiceUnavailable, "not ready"},
+		{"loaded_ok", true, nil, http.StatusOK, "ready"},
+		{"loaded_with_errors", true, []error{fmt.Errorf("TASK_BAD: invalid cron expression")}, http.StatusServiceUnavailable, "TASK_BAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &healthState{maxAge: defaultHealthMaxAge}
+			if tt.loaded {
+				h.setLoaded(1, tt.errs)
+			}
+
+			rec := httptest.NewRecorder()
+			healthHandler(h).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status %d, got %d", tt.statusCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("expected body to contain %q, got %s", tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRunHealthcheck verifies the healthcheck subcommand against a live server
+func TestRunHealthcheck(t *testing.T) {
+	h := &healthState{maxAge: defaultHealthMaxAge}
+	h.setLoaded(1, nil)
+
+	srv := httptest.NewServer(healthHandler(h))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	if code := runHealthcheck([]string{"-addr", addr}); code != 1 {
+		t.Errorf("expected exit code 1 without heartbeat, got %d", code)
+	}
+
+	h.beat(time.Now())
+	if code := runHealthcheck([]string{"-addr", addr}); code != 0 {
+		t.Errorf("expected exit code 0 when healthy, got %d", code)
+	}
+
+	if code := runHealthcheck([]string{"-addr", "invalid"}); code != 2 {
+		t.Errorf("expected exit code 2 for invalid address, got %d", code)
+	}
+}
+
+// TestProbeBaseURL verifies listen addresses are converted to reachable URLs
+func TestProbeBaseURL(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{":8080", "http://127.0.0.1:8080"},
+		{"0.0.0.0:9000", "http://127.0.0.1:9000"},
+		{"10.0.0.5:8080", "http://10.0.0.5:8080"},
+	}
+
+	for _, tt := range tests {
+		got, err := probeBaseURL(tt.addr)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", tt.addr, err)
+		} else if got != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultHistoryRuns is the number of runs kept per task unless configured.
+const defaultHistoryRuns = 100
+
+// historyStore keeps a bounded history of runs per task. When dir is set,
+// runs are stored as one JSON Lines file per task so that they survive
+// restarts; otherwise they are kept in memory only.
+type historyStore struct {
+	mu      sync.Mutex
+	dir     string
+	maxRuns int
+	maxAge  time.Duration
+	memory  map[string][]runRecord
+}
+
+// runHistory is the process-wide run history.
+var runHistory = &historyStore{maxRuns: defaultHistoryRuns}
+
+// newHistoryStore creates a history store. An empty dir keeps history in
+// memory; maxAge of zero disables age-based retention.
+func newHistoryStore(dir string, maxRuns int, maxAge time.Duration) (*historyStore, error) {
+	if maxRuns <= 0 {
+		return nil, fmt.Errorf("history must keep at least one run, got %d", maxRuns)
+	}
+	if maxAge < 0 {
+		return nil, fmt.Errorf("history max age must not be negative, got %v", maxAge)
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &historyStore{dir: dir, maxRuns: maxRuns, maxAge: maxAge}, nil
+}
+
+// historyStoreFromEnv creates a history store configured by GRON_HISTORY_DIR,
+// GRON_HISTORY_MAX_RUNS and GRON_HISTORY_MAX_AGE.
+func historyStoreFromEnv() (*historyStore, error) {
+	maxRuns := defaultHistoryRuns
+	if v := os.Getenv("GRON_HISTORY_MAX_RUNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GRON_HISTORY_MAX_RUNS '%s': %v", v, err)
+		}
+		maxRuns = n
+	}
+
+	var maxAge time.Duration
+	if v := os.Getenv("GRON_HISTORY_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GRON_HISTORY_MAX_AGE '%s': %v", v, err)
+		}
+		maxAge = d
+	}
+
+	return newHistoryStore(os.Getenv("GRON_HISTORY_DIR"), maxRuns, maxAge)
+}
+
+// unsafeFileChars matches characters not allowed in history file names.
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// path returns the file holding the history of a task.
+func (h *historyStore) path(task string) string {
+	return filepath.Join(h.dir, unsafeFileChars.ReplaceAllString(task, "_")+".jsonl")
+}
+
+// add records a run and applies retention to the history of its task.
+func (h *historyStore) add(record runRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records, err := h.load(record.Task)
+	if err != nil {
+		return err
+	}
+	records = h.retain(append(records, record), time.Now())
+
+	if h.dir == "" {
+		if h.memory == nil {
+			h.memory = make(map[string][]runRecord)
+		}
+		h.memory[record.Task] = records
+		return nil
+	}
+	return h.save(record.Task, records)
+}
+
+// list returns up to limit runs of a task, newest first. A limit of zero
+// returns all retained runs.
+func (h *historyStore) list(task string, limit int) ([]runRecord, error) {
+	h.mu.Lock()
+	records, err := h.load(task)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	records = h.retain(records, time.Now())
+	result := make([]runRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		result = append(result, records[i])
+	}
+	return result, nil
+}
+
+// last returns the most recent run of a task, or nil if it never ran.
+func (h *historyStore) last(task string) *runRecord {
+	records, err := h.list(task, 1)
+	if err != nil || len(records) == 0 {
+		return nil
+	}
+	return &records[0]
+}
+
+// retain drops runs beyond the count and age limits, oldest first.
+func (h *historyStore) retain(records []runRecord, now time.Time) []runRecord {
+	if h.maxAge > 0 {
+		cutoff := now.Add(-h.maxAge)
+		i := 0
+		for i < len(records) && records[i].End.Before(cutoff) {
+			i++
+		}
+		records = records[i:]
+	}
+	if len(records) > h.maxRuns {
+		records = records[len(records)-h.maxRuns:]
+	}
+	return records
+}
+
+// load reads the history of a task, oldest first. Must be called with h.mu held.
+func (h *historyStore) load(task string) ([]runRecord, error) {
+	if h.dir == "" {
+		return append([]runRecord(nil), h.memory[task]...), nil
+	}
+
+	data, err := os.ReadFile(h.path(task))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []runRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record runRecord
+		// Skip lines that cannot be decoded, e.g. after a crash mid-write.
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// save atomically replaces the history of a task. Must be called with h.mu held.
+func (h *historyStore) save(task string, records []runRecord) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	path := h.path(task)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newRunID returns a random identifier for a run.
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// runHistoryCommand implements the "history" subcommand, which reads the run
+// history directly from GRON_HISTORY_DIR.
+func runHistoryCommand(args []string) int {
+	return historyCommand(args, os.Stdout, os.Stderr)
+}
+
+// historyCommand prints the run history of a task.
+func historyCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", os.Getenv("GRON_HISTORY_DIR"), "history directory (defaults to $GRON_HISTORY_DIR)")
+	limit := fs.Int("n", 10, "number of runs to show, 0 for all")
+	output := fs.Bool("output", false, "include the output of each run")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: gron history [-dir path] [-n count] [-output] <task>")
+		return 2
+	}
+	if *dir == "" {
+		fmt.Fprintln(stderr, "history: no directory given and GRON_HISTORY_DIR is not set")
+		return 2
+	}
+
+	store := &historyStore{dir: *dir, maxRuns: int(^uint(0) >> 1)}
+	records, err := store.list(fs.Arg(0), *limit)
+	if err != nil {
+		fmt.Fprintf(stderr, "history: %v\n", err)
+		return 1
+	}
+
+	if *output {
+		printLogs(stdout, records)
+		return 0
+	}
+	printRuns(stdout, records)
+	return 0
+}
+
+// printRuns writes a table of runs.
+func printRuns(w io.Writer, records []runRecord) {
+	tw := newTable(w)
+	fmt.Fprintln(tw, "ID\tSTART\tDURATION\tTRIGGER\tEXIT")
+	for _, run := range records {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\t%d\n", run.ID, run.Start.Format(time.RFC3339), run.Duration().Round(time.Millisecond), run.Trigger, run.ExitCode)
+	}
+	tw.Flush()
+}
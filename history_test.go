@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestHistory replaces the global run history with an empty in-memory store
+func useTestHistory(t *testing.T) {
+	t.Helper()
+	original := runHistory
+	runHistory = &historyStore{maxRuns: defaultHistoryRuns}
+	t.Cleanup(func() { runHistory = original })
+}
+
+// testRun creates a run record that ended at the given time
+func testRun(task string, end time.Time, exitCode int) runRecord {
+	return runRecord{
+		ID:       newRunID(),
+		Task:     task,
+		Trigger:  triggerScheduled,
+		Start:    end.Add(-time.Second),
+		End:      end,
+		ExitCode: exitCode,
+		Output:   fmt.Sprintf("output at %s", end.Format(time.RFC3339)),
+	}
+}
+
+// TestHistoryStoreRetention verifies count and age based retention
+func TestHistoryStoreRetention(t *testing.T) {
+	now := time.Now()
+
+	for _, dir := range []string{"", t.TempDir()} {
+		name := "memory"
+		if dir != "" {
+			name = "file"
+		}
+		t.Run(name, func(t *testing.T) {
+			store, err := newHistoryStore(dir, 3, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+
+			// One run too old, then five recent runs
+			store.add(testRun("backup", now.Add(-48*time.Hour), 0))
+			for i := 5; i >= 1; i-- {
+				if err := store.add(testRun("backup", now.Add(-time.Duration(i)*time.Minute), i)); err != nil {
+					t.Fatalf("failed to add run: %v", err)
+				}
+			}
+			store.add(testRun("other", now, 0))
+
+			runs, err := store.list("backup", 0)
+			if err != nil {
+				t.Fatalf("failed to list runs: %v", err)
+			}
+			if len(runs) != 3 {
+				t.Fatalf("expected 3 runs, got %d", len(runs))
+			}
+			// Newest first
+			if runs[0].ExitCode != 1 || runs[2].ExitCode != 3 {
+				t.Errorf("unexpected order: %d, %d, %d", runs[0].ExitCode, runs[1].ExitCode, runs[2].ExitCode)
+			}
+
+			if limited, _ := store.list("backup", 2); len(limited) != 2 {
+				t.Errorf("expected 2 runs with limit, got %d", len(limited))
+			}
+			if last := store.last("backup"); last == nil || last.ExitCode != 1 {
+				t.Errorf("unexpected last run: %+v", last)
+			}
+			if last := store.last("missing"); last != nil {
+				t.Errorf("expected no last run for unknown task, got %+v", last)
+			}
+		})
+	}
+}
+
+// TestHistoryStorePersistence verifies runs survive reopening the store
+func TestHistoryStorePersistence(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := newHistoryStore(dir, 10, 0)
+	store.add(testRun("backup", time.Now(), 0))
+
+	// Simulate a partially written line after a crash
+	f, _ := os.OpenFile(filepath.Join(dir, "backup.jsonl"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"id":"broken`)
+	f.Close()
+
+	reopened, _ := newHistoryStore(dir, 10, 0)
+	runs, err := reopened.list("backup", 0)
+	if err != nil {
+		t.Fatalf("failed to list runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Errorf("expected 1 run after reopening, got %d", len(runs))
+	}
+}
+
+// TestHistoryStoreInvalidConfig verifies retention settings are validated
+func TestHistoryStoreInvalidConfig(t *testing.T) {
+	if _, err := newHistoryStore("", 0, 0); err == nil {
+		t.Error("expected error for zero max runs")
+	}
+	if _, err := newHistoryStore("", 10, -time.Hour); err == nil {
+		t.Error("expected error for negative max age")
+	}
+}
+
+// TestHistoryCommand verifies the history subcommand reads the store directory
+func TestHistoryCommand(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := newHistoryStore(dir, 10, 0)
+	run := testRun("backup", time.Now(), 2)
+	store.add(run)
+
+	var stdout, stderr bytes.Buffer
+	if code := historyCommand([]string{"-dir", dir, "backup"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), run.ID) {
+		t.Errorf("expected output to contain run ID, got %s", stdout.String())
+	}
+
+	stdout.Reset()
+	historyCommand([]string{"-dir", dir, "-output", "backup"}, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), run.Output) {
+		t.Errorf("expected output to contain run output, got %s", stdout.String())
+	}
+
+	if code := historyCommand([]string{"-dir", dir}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected usage error without task, got %d", code)
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Conditions for mailing the output of a run.
+const (
+	mailOnOutput  = "output"
+	mailOnFailure = "failure"
+	mailOnAlways  = "always"
+)
+
+// mailTarget describes who receives the output of a task's runs and when.
+type mailTarget struct {
+	to []string
+	on string
+}
+
+// newMailTarget creates a mail target from a comma-separated recipient list
+// and a condition. An empty recipient list disables mail, like MAILTO="".
+func newMailTarget(to, on string) (*mailTarget, error) {
+	target := &mailTarget{on: on}
+	if target.on == "" {
+		target.on = mailOnOutput
+	}
+	switch target.on {
+	case mailOnOutput, mailOnFailure, mailOnAlways:
+	default:
+		return nil, fmt.Errorf("unknown mail condition '%s'", on)
+	}
+
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			target.to = append(target.to, addr)
+		}
+	}
+	return target, nil
+}
+
+// wants reports whether a run should be mailed.
+func (m *mailTarget) wants(record runRecord) bool {
+	if len(m.to) == 0 {
+		return false
+	}
+	switch m.on {
+	case mailOnAlways:
+		return true
+	case mailOnFailure:
+		return record.ExitCode != 0
+	default:
+		return strings.TrimSpace(record.Output) != ""
+	}
+}
+
+// smtpConfig holds the settings used to connect to the SMTP server.
+type smtpConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	security string // "starttls", "tls", "none" or "auto"
+	auth     string // "plain", "crammd5" or "none"
+}
+
+// send delivers a message to the SMTP server.
+func (c smtpConfig) send(to []string, msg []byte) error {
+	addr := net.JoinHostPort(c.host, strconv.Itoa(c.port))
+	tlsConfig := &tls.Config{ServerName: c.host}
+
+	var conn net.Conn
+	var err error
+	if c.security == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 30*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if hostname, err := os.Hostname(); err == nil {
+		if err := client.Hello(hostname); err != nil {
+			return err
+		}
+	}
+
+	if c.security == "starttls" || c.security == "auto" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if c.security == "starttls" {
+			return fmt.Errorf("server %s does not support STARTTLS", addr)
+		}
+	}
+
+	switch c.auth {
+	case "plain":
+		err = client.Auth(smtp.PlainAuth("", c.username, c.password, c.host))
+	case "crammd5":
+		err = client.Auth(smtp.CRAMMD5Auth(c.username, c.password))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// mailer batches run reports per recipient list and sends them periodically
+// so that a burst of failures results in a single mail.
+type mailer struct {
+	mu       sync.Mutex
+	config   smtpConfig
+	global   *mailTarget
+	interval time.Duration
+	pending  map[string][]notification
+	send     func(to []string, msg []byte) error
+	done     chan struct{} // Closed by stop.
+}
+
+// defaultMailer is the process-wide mailer, nil if mail is not configured.
+var defaultMailer *mailer
+
+// newMailer creates a mailer. An interval of zero sends every report at once.
+func newMailer(config smtpConfig, global *mailTarget, interval time.Duration) *mailer {
+	return &mailer{
+		config:   config,
+		global:   global,
+		interval: interval,
+		pending:  make(map[string][]notification),
+		send:     config.send,
+		done:     make(chan struct{}),
+	}
+}
+
+// notify queues the report of a run if the task's mail target wants it.
+// Tasks without their own target use the global one.
+func (m *mailer) notify(task *CronSchedule, n notification, record runRecord) {
+	target := task.mail
+	if target == nil {
+		target = m.global
+	}
+	if target == nil || !target.wants(record) {
+		return
+	}
+
+	key := strings.Join(target.to, ",")
+	m.mu.Lock()
+	m.pending[key] = append(m.pending[key], n)
+	m.mu.Unlock()
+
+	if m.interval == 0 {
+		m.flush()
+	}
+}
+
+// flush sends all queued reports, one mail per recipient list.
+func (m *mailer) flush() {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[string][]notification)
+	m.mu.Unlock()
+
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		to := strings.Split(key, ",")
+		msg := formatMail(m.config.from, to, pending[key], time.Now())
+		if err := m.send(to, msg); err != nil {
+			log.Printf("Failed to send mail to %s: %v", key, err)
+		}
+	}
+}
+
+// run flushes queued reports every interval until the mailer is stopped.
+func (m *mailer) run() {
+	if m.interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.flush()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// stop stops the mailer and sends the reports still waiting for the next batch.
+func (m *mailer) stop() {
+	close(m.done)
+	m.flush()
+}
+
+// formatMail builds an RFC 5322 message reporting one or more runs.
+func formatMail(from string, to []string, reports []notification, now time.Time) []byte {
+	var subject string
+	if len(reports) == 1 {
+		r := reports[0]
+		subject = fmt.Sprintf("gron: task %s %s (exit %d)", r.Task, mailVerb(r), r.ExitCode)
+	} else {
+		failed := 0
+		for _, r := range reports {
+			if r.ExitCode != 0 {
+				failed++
+			}
+		}
+		subject = fmt.Sprintf("gron: %d task runs, %d failed", len(reports), failed)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+
+	for i, r := range reports {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		fmt.Fprintf(&buf, "Task:     %s\r\n", r.Task)
+		fmt.Fprintf(&buf, "Schedule: %s\r\n", r.Schedule)
+		fmt.Fprintf(&buf, "Command:  %s\r\n", r.Command)
+		fmt.Fprintf(&buf, "Started:  %s\r\n", r.Start.Format(time.RFC3339))
+		fmt.Fprintf(&buf, "Duration: %v\r\n", r.Duration())
+		fmt.Fprintf(&buf, "Exit:     %d\r\n", r.ExitCode)
+		if r.Output != "" {
+			buf.WriteString("\r\n")
+			// Lines starting with a dot are escaped by the SMTP client.
+			for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+				buf.WriteString(strings.TrimRight(line, "\r") + "\r\n")
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// mailVerb describes the outcome of a run for a mail subject.
+func mailVerb(n notification) string {
+	if n.ExitCode != 0 {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+// mailOptions are the per-task mail settings read from the environment.
+var mailOptions = []string{"TO", "ON"}
+
+// loadMailer configures mail from environment variables. MAILTO (or
+// GRON_MAIL_TO) and GRON_MAIL_ON set the global target, GRON_MAIL_<TASK>_TO
+// and GRON_MAIL_<TASK>_ON a target for a single task, and GRON_SMTP_* the
+// server. Returns nil if no recipients are configured.
+func loadMailer(environ []string, tasks []*CronSchedule) (*mailer, error) {
+	settings, err := scopedSettings(environ, "GRON_MAIL_", mailOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string)
+	for _, entry := range environ {
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	byName := make(map[string]*CronSchedule)
+	for _, task := range tasks {
+		byName[task.name] = task
+	}
+
+	// GRON_MAIL_TO takes precedence over the classic MAILTO.
+	globalTo, hasGlobalTo := env["GRON_MAIL_TO"]
+	if !hasGlobalTo {
+		globalTo = env["MAILTO"]
+	}
+
+	global, err := newMailTarget(globalTo, settings[""]["ON"])
+	if err != nil {
+		return nil, fmt.Errorf("GRON_MAIL_ON: %v", err)
+	}
+	configured := len(global.to) > 0
+
+	for _, name := range sortedNames(settings) {
+		if name == "" {
+			continue
+		}
+		opts := settings[name]
+		prefix := "GRON_MAIL_" + strings.ToUpper(name) + "_"
+
+		task, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%sTO: unknown task '%s'", prefix, name)
+		}
+
+		// Without its own recipients a task only overrides the condition.
+		to, hasTo := opts["TO"]
+		if !hasTo {
+			to = globalTo
+		}
+		on, hasOn := opts["ON"]
+		if !hasOn {
+			on = global.on
+		}
+
+		target, err := newMailTarget(to, on)
+		if err != nil {
+			return nil, fmt.Errorf("%sON: %v", prefix, err)
+		}
+		if len(target.to) > 0 {
+			configured = true
+		}
+		task.mail = target
+	}
+
+	if !configured {
+		return nil, nil
+	}
+
+	config, err := smtpConfigFromEnv(env)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Minute
+	if v := env["GRON_SMTP_BATCH_INTERVAL"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid GRON_SMTP_BATCH_INTERVAL '%s'", v)
+		}
+		interval = d
+	}
+
+	return newMailer(config, global, interval), nil
+}
+
+// smtpConfigFromEnv reads the SMTP server settings from GRON_SMTP_HOST,
+// GRON_SMTP_PORT, GRON_SMTP_USERNAME, GRON_SMTP_PASSWORD, GRON_SMTP_FROM,
+// GRON_SMTP_SECURITY and GRON_SMTP_AUTH.
+func smtpConfigFromEnv(env map[string]string) (smtpConfig, error) {
+	config := smtpConfig{
+		host:     env["GRON_SMTP_HOST"],
+		username: env["GRON_SMTP_USERNAME"],
+		password: env["GRON_SMTP_PASSWORD"],
+		from:     env["GRON_SMTP_FROM"],
+		security: env["GRON_SMTP_SECURITY"],
+		auth:     env["GRON_SMTP_AUTH"],
+	}
+
+	if config.host == "" {
+		return config, fmt.Errorf("GRON_SMTP_HOST is required to send mail")
+	}
+
+	if config.security == "" {
+		config.security = "auto"
+	}
+	switch config.security {
+	case "auto", "starttls", "none":
+		config.port = 25
+	case "tls":
+		config.port = 465
+	default:
+		return config, fmt.Errorf("invalid GRON_SMTP_SECURITY '%s'", config.security)
+	}
+
+	if v := env["GRON_SMTP_PORT"]; v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid GRON_SMTP_PORT '%s'", v)
+		}
+		config.port = port
+	}
+
+	if config.auth == "" {
+		config.auth = "none"
+		if config.username != "" {
+			config.auth = "plain"
+		}
+	}
+	switch config.auth {
+	case "none", "plain", "crammd5":
+	default:
+		return config, fmt.Errorf("invalid GRON_SMTP_AUTH '%s'", config.auth)
+	}
+
+	if config.from == "" {
+		hostname, _ := os.Hostname()
+		config.from = "gron@" + hostname
+	}
+
+	return config, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal local SMTP server recording received messages
+type smtpSink struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []sinkMessage
+	auths    []string
+}
+
+// sinkMessage is a message received by the SMTP sink
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPSink starts an SMTP sink on a random local port
+func newSMTPSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SMTP sink: %v", err)
+	}
+	sink := &smtpSink{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return sink
+}
+
+// serve handles a single SMTP session
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 sink ready")
+	var msg sinkMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-sink")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auths = append(s.auths, line)
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case "MAIL":
+			msg = sinkMessage{from: line}
+			reply("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// received returns the messages received so far
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+// config returns an SMTP configuration pointing at the sink
+func (s *smtpSink) config() smtpConfig {
+	port := s.listener.Addr().(*net.TCPAddr).Port
+	return smtpConfig{host: "127.0.0.1", port: port, from: "gron@test", security: "auto", auth: "none"}
+}
+
+// TestMailTargetWants verifies the conditions for mailing a run
+func TestMailTargetWants(t *testing.T) {
+	tests := []struct {
+		name     string
+		to       string
+		on       string
+		record   runRecord
+		expected bool
+	}{
+		{"output_with_output", "ops@example.com", "", runRecord{Output: "hello"}, true},
+		{"output_without_output", "ops@example.com", "", runRecord{Output: " \n"}, false},
+		{"failure_on_success", "ops@example.com", "failure", runRecord{Output: "hello"}, false},
+		{"failure_on_failure", "ops@example.com", "failure", runRecord{ExitCode: 1}, true},
+		{"always", "ops@example.com", "always", runRecord{}, true},
+		{"disabled", "", "always", runRecord{ExitCode: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := newMailTarget(tt.to, tt.on)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := target.wants(tt.record); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := newMailTarget("ops@example.com", "sometimes"); err == nil {
+		t.Error("expected error for unknown condition")
+	}
+}
+
+// TestSMTPSend verifies delivery to a local SMTP sink with authentication
+func TestSMTPSend(t *testing.T) {
+	sink := newSMTPSink(t)
+	config := sink.config()
+	config.auth = "plain"
+	config.username = "gron"
+	config.password = "secret"
+
+	report := notification{Task: "backup", ExitCode: 1, Output: ".hidden\nline two\n"}
+	msg := formatMail(config.from, []string{"ops@example.com"}, []notification{report}, time.Now())
+	if err := config.send([]string{"ops@example.com"}, msg); err != nil {
+		t.Fatalf("failed to send mail: %v", err)
+	}
+
+	messages := sink.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].to[0], "ops@example.com") {
+		t.Errorf("unexpected recipient: %v", messages[0].to)
+	}
+	if !strings.Contains(messages[0].data, "Subject: gron: task backup failed (exit 1)") {
+		t.Errorf("unexpected message: %s", messages[0].data)
+	}
+	// The SMTP client must dot-stuff lines starting with a dot
+	if !strings.Contains(messages[0].data, "\r\n..hidden\r\n") {
+		t.Errorf("expected dot-stuffed output, got %s", messages[0].data)
+	}
+	if len(sink.auths) != 1 || !strings.HasPrefix(sink.auths[0], "AUTH PLAIN") {
+		t.Errorf("expected PLAIN authentication, got %v", sink.auths)
+	}
+
+	// STARTTLS is required but the sink does not offer it
+	config.security = "starttls"
+	if err := config.send([]string{"ops@example.com"}, msg); err == nil {
+		t.Error("expected error when STARTTLS is not supported")
+	}
+}
+
+// TestMailerBatching verifies reports are batched per recipient list
+func TestMailerBatching(t *testing.T) {
+	sink := newSMTPSink(t)
+	global, _ := newMailTarget("ops@example.com", "failure")
+	m := newMailer(sink.config(), global, time.Hour)
+
+	backup := &CronSchedule{name: "backup", command: "backup.sh"}
+	report := &CronSchedule{name: "report", command: "report.sh"}
+	report.mail, _ = newMailTarget("reports@example.com", "always")
+
+	for i := 0; i < 3; i++ {
+		record := runRecord{ExitCode: 1, Output: "failed " + strconv.Itoa(i)}
+		m.notify(backup, newNotification(eventFailure, backup, record), record)
+	}
+	ok := runRecord{Output: "done"}
+	m.notify(backup, newNotification(eventSuccess, backup, ok), ok)
+	m.notify(report, newNotification(eventSuccess, report, ok), ok)
+
+	if len(sink.received()) != 0 {
+		t.Fatal("expected no mail before the batch is flushed")
+	}
+
+	m.flush()
+	messages := sink.received()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0].data, "Subject: gron: 3 task runs, 3 failed") {
+		t.Errorf("unexpected batched message: %s", messages[0].data)
+	}
+	if !strings.Contains(messages[1].data, "Subject: gron: task report succeeded (exit 0)") {
+		t.Errorf("unexpected per-task message: %s", messages[1].data)
+	}
+}
+
+// TestLoadMailer verifies mail configuration from environment variables
+func TestLoadMailer(t *testing.T) {
+	tasks := []*CronSchedule{{name: "backup"}, {name: "report"}, {name: "quiet"}}
+	environ := []string{
+		"MAILTO=ops@example.com",
+		"GRON_MAIL_ON=failure",
+		"GRON_MAIL_REPORT_TO=reports@example.com, boss@example.com",
+		"GRON_MAIL_BACKUP_ON=always",
+		"GRON_MAIL_QUIET_TO=",
+		"GRON_SMTP_HOST=smtp.example.com",
+		"GRON_SMTP_PORT=587",
+		"GRON_SMTP_USERNAME=gron",
+		"GRON_SMTP_SECURITY=starttls",
+	}
+
+	m, err := loadMailer(environ, tasks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m == nil {
+		t.Fatal("expected mailer to be configured")
+	}
+	if m.config.port != 587 || m.config.auth != "plain" || m.interval != time.Minute {
+		t.Errorf("unexpected SMTP config: %+v, interval %v", m.config, m.interval)
+	}
+	if m.global.on != mailOnFailure || m.global.to[0] != "ops@example.com" {
+		t.Errorf("unexpected global target: %+v", m.global)
+	}
+	if tasks[0].mail.on != mailOnAlways || tasks[0].mail.to[0] != "ops@example.com" {
+		t.Errorf("unexpected backup target: %+v", tasks[0].mail)
+	}
+	if len(tasks[1].mail.to) != 2 || tasks[1].mail.on != mailOnFailure {
+		t.Errorf("unexpected report target: %+v", tasks[1].mail)
+	}
+	if tasks[2].mail.wants(runRecord{ExitCode: 1}) {
+		t.Error("expected mail to be disabled for quiet")
+	}
+
+	if m, err := loadMailer([]string{"PATH=/bin"}, tasks); m != nil || err != nil {
+		t.Errorf("expected no mailer without recipients, got %v, %v", m, err)
+	}
+
+	invalid := [][]string{
+		{"MAILTO=ops@example.com"},
+		{"MAILTO=ops@example.com", "GRON_SMTP_HOST=x", "GRON_SMTP_SECURITY=ssl"},
+		{"MAILTO=ops@example.com", "GRON_SMTP_HOST=x", "GRON_SMTP_BATCH_INTERVAL=soon"},
+		{"GRON_MAIL_MISSING_TO=ops@example.com", "GRON_SMTP_HOST=x"},
+	}
+	for _, env := range invalid {
+		if _, err := loadMailer(env, tasks); err == nil {
+			t.Errorf("expected error for %v", env)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -26,25 +29,65 @@ var cronFields = []CronField{
 	{0, 6},  // Days of the week
 }
 
+// dayNames and monthNames are the names accepted in the day of week and
+// month fields, by number.
+var (
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+)
+
 // CronSchedule represents a parsed cron expression and the associated command.
 type CronSchedule struct {
-	minutes     []int
-	hours       []int
-	daysOfMonth []int
-	months      []int
-	daysOfWeek  []int
-	command     string
-	interval    time.Duration // Duration for @every format.
-	isEvery     bool          // Flag to indicate @every format.
+	minutes      []int
+	hours        []int
+	daysOfMonth  []int
+	months       []int
+	daysOfWeek   []int
+	command      string
+	interval     time.Duration // Duration for @every format.
+	isEvery      bool          // Flag to indicate @every format.
+	name         string        // Task name derived from the TASK_* variable.
+	spec         string        // Schedule expression as written by the user.
+	state        taskState     // Runtime state of the task.
+	webhooks     []*webhookTarget
+	mail         *mailTarget // Overrides the global mail target if set.
+	ping         *pingTarget // Dead-man's-switch monitor pinged around each run.
+	exec         commandOptions
+	retries      int            // Extra attempts after a failed run.
+	retryDelay   time.Duration  // Delay between attempts.
+	location     *time.Location // Time zone of the schedule; local time if nil.
+	startDelay   time.Duration  // Delay of scheduled runs after their time.
+	aligned      bool           // @every ticks fall on wall-clock multiples of the interval.
+	anchor       time.Time      // Time aligned ticks are counted from, if set.
+	offset       time.Duration  // Shift of aligned ticks.
+	isReboot     bool           // @reboot: runs once when gron starts.
+	runOnStart   bool           // Run once as soon as the task is scheduled.
+	initialDelay time.Duration  // Delay of the first @every tick.
+	jitter       time.Duration  // Maximum random delay of scheduled runs.
+	isAt         bool           // @at: runs once at a given time.
+	at           time.Time      // Time of an @at task.
+	runMissed    bool           // Run an @at task whose time passed before it was loaded.
+	years        []int          // Years of the optional sixth field; any year if nil.
+	notBefore    time.Time      // Scheduled runs start at this time, if set.
+	notAfter     time.Time      // Scheduled runs end at this time, if set.
+	skipOn       []*calendar    // Calendars of days without scheduled runs.
+	onlyOn       []*calendar    // Calendars of the only days with scheduled runs, if set.
+	window       *runWindow     // Times of day scheduled runs are limited to, if set.
+	isOnCalendar bool           // @oncalendar: a systemd calendar event.
+	zoneName     string         // Time zone written in the calendar event, if any.
+	lastDays     []int          // Days counted back from the end of the month, if set.
+	dayMatchAny  bool           // Either day field matching is enough, as in crontabs.
 }
 
 // Predefined special schedule formats (e.g., @hourly, @daily).
 var specialSchedules = map[string]string{
-	"@hourly":  "0 * * * *",
-	"@daily":   "0 0 * * *",
-	"@weekly":  "0 0 * * 0",
-	"@monthly": "0 0 1 * *",
-	"@yearly":  "0 0 1 1 *",
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@midnight": "0 0 * * *",
 }
 
 // parseField parses a single field of a cron expression.
@@ -52,7 +95,17 @@ var specialSchedules = map[string]string{
 // - "*" for any value.
 // - "*/n" for step values.
 // - Specific numbers.
+// - Ranges "a-b", optionally with a step "a-b/n", and "a/n" for "a-max/n".
+// - Month and day of week names, e.g. "jan" or "mon-fri".
+// - Comma-separated lists of the above.
+// - Jenkins-style hashed values "H", "H(a-b)", "H/n" and "H(a-b)/n".
 func parseField(field string, limits CronField) ([]int, error) {
+	return parseSeededField(field, limits, 0)
+}
+
+// parseSeededField parses a field like parseField, resolving "H" tokens
+// with the given seed.
+func parseSeededField(field string, limits CronField, seed uint32) ([]int, error) {
 	if field == "*" {
 		result := make([]int, limits.max-limits.min+1)
 		for i := range result {
@@ -61,34 +114,119 @@ func parseField(field string, limits CronField) ([]int, error) {
 		return result, nil
 	}
 
-	if strings.Contains(field, "*/") {
-		step, err := strconv.Atoi(strings.TrimPrefix(field, "*/"))
+	seen := make(map[int]bool)
+	var result []int
+	for _, part := range strings.Split(field, ",") {
+		values, err := parseFieldPart(part, limits, seed)
 		if err != nil {
 			return nil, err
 		}
-		var result []int
-		for i := limits.min; i <= limits.max; i += step {
-			result = append(result, i)
+		for _, v := range values {
+			if !seen[v] {
+				seen[v] = true
+				result = append(result, v)
+			}
 		}
-		return result, nil
 	}
+	sort.Ints(result)
+	return result, nil
+}
 
-	val, err := strconv.Atoi(field)
-	if err != nil {
-		return nil, err
+// parseFieldPart parses a single element of a comma-separated field.
+func parseFieldPart(part string, limits CronField, seed uint32) ([]int, error) {
+	// Allow 7 to represent Sunday in the "day of the week" field.
+	max := limits.max
+	if limits.min == 0 && limits.max == 6 {
+		max = 7
 	}
 
-	// Allow 7 to represent Sunday in the "day of the week" field.
-	if (limits.min == 0 && limits.max == 6 && val == 7) || (val >= limits.min && val <= limits.max) {
-		return []int{val}, nil
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepPart); err != nil {
+			return nil, fmt.Errorf("invalid step '%s'", stepPart)
+		}
+		if step <= 0 {
+			return nil, fmt.Errorf("step must be positive, got %d", step)
+		}
 	}
 
-	return nil, fmt.Errorf("value %d out of range for field", val)
+	start, end := limits.min, limits.max
+	if strings.HasPrefix(rangePart, "H") {
+		return parseHashPart(rangePart, step, hasStep, limits, seed)
+	}
+	if rangePart != "*" {
+		lo, hi, isRange := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = fieldValue(lo, limits, false); err != nil {
+			return nil, err
+		}
+		end = start
+		if isRange {
+			if end, err = fieldValue(hi, limits, true); err != nil {
+				return nil, err
+			}
+		} else if hasStep {
+			end = limits.max
+		}
+		for _, v := range []int{start, end} {
+			if v < limits.min || v > max {
+				return nil, fmt.Errorf("value %d out of range %d-%d", v, limits.min, limits.max)
+			}
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid range %d-%d", start, end)
+		}
+	}
+
+	var result []int
+	for i := start; i <= end; i += step {
+		result = append(result, i)
+	}
+	return result, nil
 }
 
+// fieldValue parses a single value of a field: a number or, in the month and
+// day of week fields, a name such as "jan" or "mon" in any case. Sunday is 7
+// at the end of a range, so that "fri-sun" runs from Friday through Sunday.
+func fieldValue(value string, limits CronField, end bool) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, nil
+	}
+	switch limits {
+	case cronFields[3]:
+		for i, name := range monthNames {
+			if strings.EqualFold(value, name) {
+				return i + 1, nil
+			}
+		}
+	case cronFields[4]:
+		for i, name := range dayNames {
+			if strings.EqualFold(value, name) {
+				if i == 0 && end {
+					return 7, nil
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid value '%s'", value)
+}
+
+// cronFieldNames are the names of the cron expression fields, used in errors
+// and descriptions.
+var cronFieldNames = []string{"minute", "hour", "day of month", "month", "day of week"}
+
 // parseCronSchedule parses a complete cron expression into a CronSchedule.
 // Supports standard cron format and special formats (e.g., @hourly).
 func parseCronSchedule(cronExpr string) (*CronSchedule, error) {
+	return parseCronScheduleFor(cronExpr, "")
+}
+
+// parseCronScheduleFor parses a cron expression of the named task. "H"
+// tokens resolve to values derived from the name.
+func parseCronScheduleFor(cronExpr, name string) (*CronSchedule, error) {
 	// Check for special formats.
 	if strings.HasPrefix(cronExpr, "@") {
 		if schedule, ok := specialSchedules[cronExpr]; ok {
@@ -103,29 +241,27 @@ func parseCronSchedule(cronExpr string) (*CronSchedule, error) {
 		return nil, fmt.Errorf("invalid cron expression")
 	}
 
+	if len(fields) > 6 {
+		return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields, got %d", len(fields))
+	}
+
 	schedule := &CronSchedule{}
 
 	// Parse each field.
-	var err error
-	schedule.minutes, err = parseField(fields[0], cronFields[0])
-	if err != nil {
-		return nil, err
-	}
-	schedule.hours, err = parseField(fields[1], cronFields[1])
-	if err != nil {
-		return nil, err
-	}
-	schedule.daysOfMonth, err = parseField(fields[2], cronFields[2])
-	if err != nil {
-		return nil, err
-	}
-	schedule.months, err = parseField(fields[3], cronFields[3])
-	if err != nil {
-		return nil, err
+	targets := []*[]int{&schedule.minutes, &schedule.hours, &schedule.daysOfMonth, &schedule.months, &schedule.daysOfWeek}
+	for i, target := range targets {
+		values, err := parseSeededField(fields[i], cronFields[i], fieldSeed(name, i))
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%s) '%s': %v", i+1, cronFieldNames[i], fields[i], err)
+		}
+		*target = values
 	}
-	schedule.daysOfWeek, err = parseField(fields[4], cronFields[4])
-	if err != nil {
-		return nil, err
+	if len(fields) == 6 {
+		years, err := parseYearField(fields[5])
+		if err != nil {
+			return nil, err
+		}
+		schedule.years = years
 	}
 
 	return schedule, nil
@@ -142,32 +278,138 @@ func (s *CronSchedule) shouldRun(t time.Time) bool {
 		return false
 	}
 
-	dayOfWeek := int(t.Weekday())
+	if !s.activeAt(t) || !s.inWindow(t) {
+		return false
+	}
+	if s.location != nil {
+		t = t.In(s.location)
+	}
 
-	return contains(s.minutes, t.Minute()) &&
+	return (s.years == nil || contains(s.years, t.Year())) &&
+		contains(s.minutes, t.Minute()) &&
 		contains(s.hours, t.Hour()) &&
-		contains(s.daysOfMonth, t.Day()) &&
 		contains(s.months, int(t.Month())) &&
-		// Проверяем оба возможных представления воскресенья (0 и 7)
-		(contains(s.daysOfWeek, dayOfWeek) || (dayOfWeek == 0 && contains(s.daysOfWeek, 7)))
+		s.matchesDay(t)
 }
 
-// parseEveryFormat parses the @every duration format.
-// Example: "@every 1h30m".
-func parseEveryFormat(duration string) (*CronSchedule, error) {
-	durationStr := strings.TrimPrefix(duration, "@every ")
+// matchesDay reports whether the day of t matches the day of month and day
+// of week fields: both of them, or either one if dayMatchAny is set.
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	dayOfWeek := int(t.Weekday())
+	// Проверяем оба возможных представления воскресенья (0 и 7)
+	weekday := slices.Contains(s.daysOfWeek, dayOfWeek) || (dayOfWeek == 0 && slices.Contains(s.daysOfWeek, 7))
+	if s.dayMatchAny {
+		return weekday || s.matchesDayOfMonth(t)
+	}
+	return weekday && s.matchesDayOfMonth(t)
+}
 
-	// Convert days to hours
-	if strings.HasSuffix(durationStr, "d") {
-		daysStr := strings.TrimSuffix(durationStr, "d")
-		days, err := strconv.Atoi(daysStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid days format: %v", err)
+// maxNextSearch bounds how far into the future next looks for a match.
+// Schedules such as "0 0 29 2 1" may only match once in several decades.
+const maxNextSearch = 50 * 366 * 24 * time.Hour
+
+// next returns the first time strictly after t at which the schedule should
+// run, or the zero time if there is none. For @every schedules it returns the
+// next tick after t counted from the time the ticker was started. Times
+// outside the not_before and not_after bounds and the run window of the
+// task, and on days its calendars rule out, are left out.
+func (s *CronSchedule) next(t time.Time) time.Time {
+	if !s.notBefore.IsZero() && t.Before(s.notBefore) {
+		t = s.notBefore.Add(-time.Nanosecond)
+	}
+	// Each step skips at least the rest of a day or a closed window.
+	for i := 0; i < maxCalendarDays; i++ {
+		next := s.nextUnbounded(t)
+		if next.IsZero() || !s.activeAt(next) {
+			return time.Time{}
+		}
+		if !s.inWindow(next) {
+			open := s.nextWindowOpen(next)
+			if open.IsZero() {
+				return time.Time{}
+			}
+			t = open.Add(-time.Nanosecond)
+			continue
+		}
+		if s.calendarBlock(next) == "" {
+			return next
+		}
+		t = s.endOfDay(next)
+	}
+	return time.Time{}
+}
+
+// nextUnbounded returns the next time like next, ignoring the bounds.
+func (s *CronSchedule) nextUnbounded(t time.Time) time.Time {
+	if s.isReboot {
+		return time.Time{}
+	}
+	if s.isAt {
+		if !s.at.After(t) || completions.isCompleted(s.name, s.at) {
+			return time.Time{}
+		}
+		return s.at
+	}
+	if s.isEvery && s.aligned {
+		return s.nextAlignedTick(t)
+	}
+	if s.isEvery {
+		return s.state.nextEveryTick(t, s.interval)
+	}
+
+	contains := func(arr []int, val int) bool {
+		for _, v := range arr {
+			if v == val {
+				return true
+			}
 		}
-		durationStr = fmt.Sprintf("%dh", days*24)
+		return false
+	}
+
+	if s.location != nil {
+		t = t.In(s.location)
 	}
+	limit := t.Add(maxNextSearch)
+	if s.years != nil {
+		// Years far ahead are reached by whole-year jumps.
+		limit = time.Date(s.years[len(s.years)-1]+1, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		if s.years != nil && !contains(s.years, t.Year()) {
+			if t.Year() > s.years[len(s.years)-1] {
+				break
+			}
+			// Jump to the start of the next year.
+			t = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !contains(s.months, int(t.Month())) || !s.matchesDay(t) {
+			// Jump to the start of the next day.
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !contains(s.hours, t.Hour()) {
+			// Jump to the start of the next hour.
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if !contains(s.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// parseEveryFormat parses the @every duration format.
+// Examples: "@every 1h30m", "@every 1d12h", "@every 2w", "@every 1.5d".
+func parseEveryFormat(duration string) (*CronSchedule, error) {
+	durationStr := strings.TrimPrefix(duration, "@every ")
 
-	d, err := time.ParseDuration(durationStr)
+	d, err := parseEveryInterval(durationStr)
 	if err != nil {
 		return nil, err
 	}
@@ -187,61 +429,332 @@ func parseEveryFormat(duration string) (*CronSchedule, error) {
 // 1. Standard cron: "* * * * * /path/to/command".
 // 2. @every format: "@every 1h /path/to/command".
 // 3. Special formats: "@hourly /path/to/command".
+// Tasks are named after the variable suffix and sorted by name.
+// Invalid tasks are logged and skipped.
 func loadTasks() []*CronSchedule {
-	var tasks []*CronSchedule
+	tasks, _ := parseTasks(os.Environ())
+	return tasks
+}
 
-	for _, env := range os.Environ() {
-		if strings.HasPrefix(env, "TASK_") {
-			parts := strings.SplitN(env, "=", 2)
-			if len(parts) != 2 {
-				continue
-			}
+// parseTasks parses task definitions from a list of "KEY=value" environment
+// entries. Each task is named after the lowercased suffix of its variable, so
+// TASK_BACKUP_DB becomes "backup_db", and the tasks are sorted by name.
+// Invalid tasks are logged and skipped, and the errors are returned
+// alongside the tasks that were parsed successfully.
+func parseTasks(environ []string) ([]*CronSchedule, []error) {
+	return buildEnvTasks(environ, &fileConfig{})
+}
 
-			taskDef := strings.TrimSpace(parts[1])
-			fields := strings.Fields(taskDef)
+// taskVariableOption is a task option that can be set by a companion
+// variable TASK_<NAME>_<OPTION>.
+type taskVariableOption struct {
+	name string
+	set  func(o *optionsConfig, value string) error
+}
 
-			if len(fields) < 2 {
-				log.Printf("Invalid task format: %s", taskDef)
-				continue
-			}
+// taskVariableOptions are the options of companion variables. Options that
+// end with another option come first, so RETRY_DELAY is not taken for DELAY.
+var taskVariableOptions = []taskVariableOption{
+	{"RETRY_DELAY", func(o *optionsConfig, v string) error { o.RetryDelay = v; return nil }},
+	{"START_DELAY", func(o *optionsConfig, v string) error { o.StartDelay = v; return nil }},
+	{"TIMEZONE", func(o *optionsConfig, v string) error { o.Timezone = v; return nil }},
+	{"INITIAL_DELAY", func(o *optionsConfig, v string) error { o.InitialDelay = v; return nil }},
+	{"JITTER", func(o *optionsConfig, v string) error { o.Jitter = v; return nil }},
+	{"TIMEOUT", func(o *optionsConfig, v string) error { o.Timeout = v; return nil }},
+	{"MISSED", func(o *optionsConfig, v string) error { o.Missed = v; return nil }},
+	{"NOT_BEFORE", func(o *optionsConfig, v string) error { o.NotBefore = v; return nil }},
+	{"NOT_AFTER", func(o *optionsConfig, v string) error { o.NotAfter = v; return nil }},
+	{"SKIP_ON", func(o *optionsConfig, v string) error { o.SkipOn = v; return nil }},
+	{"ONLY_ON", func(o *optionsConfig, v string) error { o.OnlyOn = v; return nil }},
+	{"WINDOW", func(o *optionsConfig, v string) error { o.Window = v; return nil }},
+	{"RETRIES", func(o *optionsConfig, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid retries '%s'", v)
+		}
+		o.Retries = &n
+		return nil
+	}},
+	{"RUN_ON_START", func(o *optionsConfig, v string) error {
+		run, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean '%s'", v)
+		}
+		o.RunOnStart = &run
+		return nil
+	}},
+	{"ENABLED", func(o *optionsConfig, v string) error {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean '%s'", v)
+		}
+		o.Enabled = &enabled
+		return nil
+	}},
+	{"SHELL", func(o *optionsConfig, v string) error { o.Shell = v; return nil }},
+	{"USER", func(o *optionsConfig, v string) error { o.User = v; return nil }},
+	{"DIR", func(o *optionsConfig, v string) error { o.Dir = v; return nil }},
+}
 
-			var schedule *CronSchedule
-			var err error
-			var command string
+// envTask is a task defined by a TASK_* variable and its companion variables.
+type envTask struct {
+	variable string
+	config   taskConfig
+}
 
-			// Check for special formats (@hourly, @daily, etc.).
-			if strings.HasPrefix(fields[0], "@") && !strings.HasPrefix(fields[0], "@every") {
-				schedule, err = parseCronSchedule(fields[0])
-				if err != nil {
-					log.Printf("Failed to parse special format '%s': %v", fields[0], err)
-					continue
-				}
-				command = strings.Join(fields[1:], " ")
-			} else if strings.HasPrefix(fields[0], "@every") {
-				everyExpr := fields[0] + " " + fields[1]
-				schedule, err = parseEveryFormat(everyExpr)
-				if err != nil {
-					log.Printf("Failed to parse @every format '%s': %v", everyExpr, err)
-					continue
-				}
-				command = strings.Join(fields[2:], " ")
-			} else {
-				// Handle standard cron format.
-				cronExpr := strings.Join(fields[:5], " ")
-				schedule, err = parseCronSchedule(cronExpr)
-				if err != nil {
-					log.Printf("Failed to parse cron expression '%s': %v", cronExpr, err)
-					continue
-				}
-				command = strings.Join(fields[5:], " ")
+// parseTaskVariables collects the tasks defined by TASK_<NAME> variables and
+// their options set by companion TASK_<NAME>_<OPTION> variables. A variable is
+// a companion if its suffix is a known option, TASK_<NAME> is defined and its
+// value is not a task definition, with the schedule aliases expanded. Tasks
+// with an invalid companion are skipped.
+func parseTaskVariables(environ []string, aliases map[string]string) (map[string]*envTask, []error) {
+	values := make(map[string]string)
+	var variables []string
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 && strings.HasPrefix(parts[0], "TASK_") {
+			values[parts[0]] = parts[1]
+			variables = append(variables, parts[0])
+		}
+	}
+
+	tasks := make(map[string]*envTask)
+	var errs []error
+	companions := make(map[string][]string)
+
+	for _, variable := range variables {
+		if parent, _ := companionOf(variable, values, aliases); parent != "" {
+			companions[parent] = append(companions[parent], variable)
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimPrefix(variable, "TASK_"))
+		if !taskNamePattern.MatchString(name) {
+			log.Printf("Invalid task name: %s", variable)
+			errs = append(errs, fmt.Errorf("%s: invalid task name, use letters, digits and underscores", variable))
+			continue
+		}
+		if other, ok := tasks[name]; ok {
+			log.Printf("Duplicate task name '%s': %s and %s", name, other.variable, variable)
+			errs = append(errs, fmt.Errorf("%s: task '%s' is already defined by %s", variable, name, other.variable))
+			continue
+		}
+
+		taskDef := strings.TrimSpace(values[variable])
+		spec, command, err := splitTaskDefinition(taskDef)
+		if err != nil {
+			log.Printf("Invalid task format: %s", taskDef)
+			if parent := definedPrefix(variable, values); parent != "" {
+				option := strings.TrimPrefix(variable, parent+"_")
+				err = fmt.Errorf("unknown option %s for %s, expected one of %s", option, parent, taskOptionNames())
 			}
+			errs = append(errs, fmt.Errorf("%s: %v", variable, err))
+			continue
+		}
+		tasks[name] = &envTask{variable: variable, config: taskConfig{Schedule: spec, Command: command}}
+	}
 
-			schedule.command = command
-			log.Printf("Scheduled task: '%s' with schedule '%s'", command, taskDef)
-			tasks = append(tasks, schedule)
+	for name, task := range tasks {
+		for _, variable := range companions[task.variable] {
+			_, option := companionOf(variable, values, aliases)
+			if err := setTaskVariableOption(&task.config.optionsConfig, option, strings.TrimSpace(values[variable])); err != nil {
+				log.Printf("Invalid task option %s: %v", variable, err)
+				errs = append(errs, fmt.Errorf("%s: %v", variable, err))
+				delete(tasks, name)
+				break
+			}
 		}
 	}
-	return tasks
+	return tasks, errs
+}
+
+// setTaskVariableOption sets the option of a companion variable after
+// validating it on its own, so that an invalid value is reported for the
+// companion variable rather than the task.
+func setTaskVariableOption(o *optionsConfig, option *taskVariableOption, value string) error {
+	var single optionsConfig
+	if err := option.set(&single, value); err != nil {
+		return err
+	}
+	if err := single.apply(&CronSchedule{}); err != nil {
+		return err
+	}
+	return option.set(o, value)
+}
+
+// companionOf returns the task variable that a companion variable belongs to
+// and the option it sets, or an empty name if it is not a companion.
+func companionOf(variable string, values, aliases map[string]string) (string, *taskVariableOption) {
+	if isTaskDefinition(values[variable], aliases) {
+		return "", nil
+	}
+	for i := range taskVariableOptions {
+		option := &taskVariableOptions[i]
+		parent := strings.TrimSuffix(variable, "_"+option.name)
+		if parent == variable || parent == "TASK" {
+			continue
+		}
+		if _, ok := values[parent]; ok {
+			return parent, option
+		}
+	}
+	return "", nil
+}
+
+// isTaskDefinition reports whether value is a schedule followed by a command,
+// which no option value is. The schedule may be one of the aliases.
+func isTaskDefinition(value string, aliases map[string]string) bool {
+	spec, _, err := splitTaskDefinition(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	_, err = parseSchedule(expandAlias(spec, aliases))
+	return err == nil
+}
+
+// definedPrefix returns the longest defined task variable that variable
+// extends with an underscore, or an empty string if there is none.
+func definedPrefix(variable string, values map[string]string) string {
+	for i := len(variable) - 1; i > len("TASK_"); i-- {
+		if variable[i] != '_' {
+			continue
+		}
+		if _, ok := values[variable[:i]]; ok {
+			return variable[:i]
+		}
+	}
+	return ""
+}
+
+// taskOptionNames lists the companion variable options for error messages.
+func taskOptionNames() string {
+	names := make([]string, 0, len(taskVariableOptions))
+	for _, option := range taskVariableOptions {
+		names = append(names, option.name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// buildEnvTasks builds the tasks defined by TASK_* variables in name order,
+// applying the defaults of the configuration file. Invalid tasks are logged
+// and skipped, and disabled tasks are left out.
+func buildEnvTasks(environ []string, cfg *fileConfig) ([]*CronSchedule, []error) {
+	defs, errs := parseTaskVariables(environ, cfg.Aliases)
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []*CronSchedule
+	for _, name := range names {
+		def := defs[name]
+		task, err := cfg.buildTask(name, def.config)
+		if err != nil {
+			log.Printf("Failed to load task '%s': %v", name, err)
+			errs = append(errs, fmt.Errorf("%s: %v", def.variable, err))
+			continue
+		}
+		if task == nil {
+			continue
+		}
+		log.Printf("Scheduled task '%s': '%s' with schedule '%s' (%s)", name, task.command, task.spec, task.describe())
+		tasks = append(tasks, task)
+	}
+	return tasks, errs
+}
+
+// splitTaskDefinition splits a "<schedule> <command>" task definition into
+// the schedule expression and the command.
+func splitTaskDefinition(taskDef string) (spec, command string, err error) {
+	fields := strings.Fields(taskDef)
+	if len(fields) < 2 {
+		return "", "", fmt.Errorf("invalid task format: %s", taskDef)
+	}
+
+	scheduleFields := scheduleFieldCount(fields)
+	if len(fields) <= scheduleFields {
+		return "", "", fmt.Errorf("invalid task format: %s", taskDef)
+	}
+
+	return strings.Join(fields[:scheduleFields], " "), strings.Join(fields[scheduleFields:], " "), nil
+}
+
+// scheduleFieldCount returns the number of fields taken by the schedule
+// expression at the start of fields.
+func scheduleFieldCount(fields []string) int {
+	switch {
+	case fields[0] == "@every":
+		return everyFieldCount(fields)
+	case fields[0] == "@at":
+		return 2
+	case fields[0] == "@oncalendar":
+		return onCalendarFieldCount(fields)
+	case strings.HasPrefix(fields[0], "@"):
+		return 1
+	case len(fields) >= 6 && yearFieldPattern.MatchString(fields[5]):
+		return 6
+	}
+	return 5
+}
+
+// parseSchedule parses a schedule expression in any supported format: a
+// standard cron expression, "@every <duration>", "@oncalendar <event>" or a
+// special format such as "@hourly". The expression is kept as the spec of
+// the returned schedule.
+func parseSchedule(spec string) (*CronSchedule, error) {
+	return parseScheduleFor(spec, "")
+}
+
+// parseScheduleFor parses the schedule expression of the named task, whose
+// name seeds the "H" tokens of cron expressions.
+func parseScheduleFor(spec, name string) (*CronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	spec = strings.Join(fields, " ")
+
+	var schedule *CronSchedule
+	var err error
+	switch {
+	case fields[0] == "@reboot":
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("unexpected fields after %s", fields[0])
+		}
+		schedule = &CronSchedule{isReboot: true}
+	case fields[0] == "@at":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid @at format: %s", spec)
+		}
+		schedule, err = parseAtFormat(fields[1])
+	case fields[0] == "@oncalendar":
+		schedule, err = parseOnCalendar(strings.Join(fields[1:], " "))
+	case fields[0] == "@every":
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid @every format: %s", spec)
+		}
+		if schedule, err = parseEveryFormat(strings.Join(fields[:2], " ")); err == nil {
+			err = parseEveryOptions(schedule, fields[2:])
+		}
+	case strings.HasPrefix(fields[0], "@"):
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("unexpected fields after %s", fields[0])
+		}
+		schedule, err = parseCronScheduleFor(spec, name)
+	default:
+		if len(fields) != 5 && len(fields) != 6 {
+			return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields, got %d", len(fields))
+		}
+		schedule, err = parseCronScheduleFor(spec, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.spec = spec
+	return schedule, nil
 }
 
 // CommandRunner u0438u043du0442u0435u0440u0444u0435u0439u0441 u0434u043bu044f u0437u0430u043fu0443u0441u043au0430 u043au043eu043cu0430u043du0434
@@ -262,26 +775,54 @@ func (r *RealCommandRunner) Run(command string, args ...string) ([]byte, error)
 var defaultCommandRunner CommandRunner = &RealCommandRunner{}
 
 // executeCommand runs the specified command using bash.
-// Logs both the command execution and its output.
-func executeCommand(command string) {
-	log.Printf("Running command: %s", command)
+// Logs both the command execution and its output, and returns the outcome.
+func executeCommand(command string) runRecord {
+	return executeCommandWith("", command, commandOptions{})
+}
+
+// executeCommandWith runs the command of the named task using bash with the
+// given working directory, environment, user and timeout. Logs identify the
+// run by the task name, or by the command if the name is empty.
+func executeCommandWith(name, command string, opts commandOptions) runRecord {
+	subject := "command " + command
+	if name != "" {
+		subject = fmt.Sprintf("task '%s'", name)
+		log.Printf("Running task '%s': %s", name, command)
+	} else {
+		log.Printf("Running command: %s", command)
+	}
+	record := runRecord{Command: command, Start: time.Now()}
 
 	// Check if bash exists, fallback to sh if not
-	shell := "/bin/bash"
-	if _, err := os.Stat(shell); os.IsNotExist(err) {
-		shell = "/bin/sh"
-		log.Printf("Bash not found, using sh instead")
+	shell := opts.shell
+	if shell == "" {
+		shell = "/bin/bash"
+		if _, err := os.Stat(shell); os.IsNotExist(err) {
+			shell = "/bin/sh"
+			log.Printf("Bash not found, using sh instead")
+		}
 	}
 
 	// u0438u0441u043fu043eu043bu044cu0437u0443u0435u043c u0438u043du0442u0435u0440u0444u0435u0439u0441 CommandRunner u0434u043bu044f u0432u043eu0437u043cu043eu0436u043du043eu0441u0442u0438 u043cu043eu043au0438u0440u043eu0432u0430u043du0438u044f
-	output, err := defaultCommandRunner.Run(shell, "-c", command)
+	var output []byte
+	var err error
+	if runner, ok := defaultCommandRunner.(OptionsCommandRunner); ok {
+		output, err = runner.RunWithOptions(opts, shell, "-c", command)
+	} else {
+		output, err = defaultCommandRunner.Run(shell, "-c", command)
+	}
+	record.End = time.Now()
+	record.Output = outputTail(output)
 
 	if err != nil {
-		log.Printf("Error executing command %s: %v", command, err)
+		log.Printf("Error executing %s: %v", subject, err)
 		// Don't exit, just log the error and continue
+		record.ExitCode = exitCode(err)
+		record.Error = err.Error()
 	}
 
-	log.Printf("Output from command %s: %s", command, string(output))
+	log.Printf("Output from %s: %s", subject, string(output))
+	return record
 }
 
 // setCommandRunner u0443u0441u0442u0430u043du0430u0432u043bu0438u0432u0430u0435u0442 u043au0430u0441u0442u043eu043cu043du044bu0439 u0438u0441u043fu043eu043bu043du0438u0442u0435u043bu044c u043au043eu043cu0430u043du0434 (u0434u043bu044f u0442u0435u0441u0442u043eu0432)
@@ -289,54 +830,119 @@ func setCommandRunner(runner CommandRunner) {
 	defaultCommandRunner = runner
 }
 
-// createEveryTickers creates tickers for tasks with @every format.
+// createEveryTickers creates tickers for tasks with @every format and
+// starts the timers of @at tasks.
 // Returns a slice of created tickers for cleanup purpose.
 func createEveryTickers(tasks []*CronSchedule) []*time.Ticker {
 	var tickers []*time.Ticker
 
 	for _, task := range tasks {
 		if task.isEvery {
-			log.Printf("Setting up @every ticker for task '%s' with interval %v", task.command, task.interval)
-			ticker := time.NewTicker(task.interval)
-			tickers = append(tickers, ticker)
-
-			go func(t *CronSchedule, tkr *time.Ticker) {
-				// Recover from panics
-				defer func() {
-					if r := recover(); r != nil {
-						log.Printf("Recovered from panic in @every ticker for '%s': %v", t.command, r)
-					}
-				}()
-
-				for range tkr.C {
-					go executeCommand(t.command)
-				}
-			}(task, ticker)
+			tickers = append(tickers, startEveryTicker(task))
+		} else if task.isAt {
+			startAtTimer(task)
 		}
 	}
 
 	return tickers
 }
 
+// startEveryTicker starts the ticker of an @every task. The ticker runs until
+// it is stopped by the caller or by the stopTicker method of the task state.
+func startEveryTicker(task *CronSchedule) *time.Ticker {
+	log.Printf("Setting up @every ticker for task '%s' with interval %v", task.name, task.interval)
+	ticker := time.NewTicker(task.interval)
+	now := time.Now()
+	firstDelayed := false
+	switch {
+	case task.aligned:
+		// Aligned tickers fire at computed wall-clock times instead.
+		ticker.Reset(task.alignedDelay(now.Add(task.initialDelay), now))
+	case task.initialDelay > 0:
+		// The first tick comes after the initial delay, the others an
+		// interval apart from it.
+		ticker.Reset(task.initialDelay)
+		now = now.Add(task.initialDelay - task.interval)
+		firstDelayed = true
+	}
+	task.state.setEveryStart(now)
+	stop := task.state.startTicker()
+
+	go func(t *CronSchedule, tkr *time.Ticker) {
+		// Recover from panics
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in @every ticker for task '%s': %v", t.name, r)
+			}
+		}()
+
+		for {
+			select {
+			case tick := <-tkr.C:
+				if t.aligned {
+					now := time.Now()
+					tkr.Reset(t.alignedDelay(now, now))
+				} else if firstDelayed {
+					tkr.Reset(t.interval)
+					firstDelayed = false
+				}
+				// Ticks outside the run window are dropped, so that the
+				// ticks keep their times.
+				if t.inWindow(tick) {
+					go runTask(t, triggerScheduled)
+				}
+			case <-stop:
+				tkr.Stop()
+				return
+			}
+		}
+	}(task, ticker)
+
+	return ticker
+}
+
 // runCronTasks runs standard cron tasks that match the current time.
 func runCronTasks(tasks []*CronSchedule, currentTime time.Time) {
 	for _, task := range tasks {
-		if !task.isEvery && task.shouldRun(currentTime) {
-			go executeCommand(task.command)
+		if task.isEvery || task.isAt || task.checkExpired(currentTime) {
+			continue
+		}
+		if task.shouldRun(currentTime) {
+			go runTask(task, triggerScheduled)
+		}
+	}
+}
+
+// runStartTasks runs the tasks that run as soon as they are scheduled: tasks
+// with run on start and, when gron boots, @reboot tasks.
+func runStartTasks(tasks []*CronSchedule, boot bool) {
+	for _, task := range tasks {
+		if task.runOnStart || (boot && task.isReboot) {
+			go runTask(task, triggerStartup)
 		}
 	}
 }
 
-// startCronScheduler starts the main cron scheduler loop.
-// This is a blocking function that runs indefinitely.
-func startCronScheduler(tasks []*CronSchedule) {
+// startCronScheduler starts the main cron scheduler loop for the tasks of
+// the registry, which may be replaced by reloads while the loop is running.
+// If started is not nil, it is closed once the tickers are set up and the
+// start tasks launched, so that reloads can begin safely.
+// This is a blocking function that runs until stop is closed; a nil stop
+// runs it indefinitely.
+func startCronScheduler(reg *taskRegistry, started chan<- struct{}, stop <-chan struct{}) {
 	// Setup and start the tickers for @every tasks
-	everyTickers := createEveryTickers(tasks)
+	createEveryTickers(reg.list())
+
+	// Run @reboot tasks and tasks that run on start
+	runStartTasks(reg.list(), true)
+	if started != nil {
+		close(started)
+	}
 
 	// Make sure to clean up all tickers when done
 	defer func() {
-		for _, ticker := range everyTickers {
-			ticker.Stop()
+		for _, task := range reg.list() {
+			task.state.stopTicker()
 		}
 	}()
 
@@ -351,31 +957,185 @@ func startCronScheduler(tasks []*CronSchedule) {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
+	// Heartbeat ticker proving to the health endpoints that the loop is alive
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	health.beat(time.Now())
+
 	// Log initial startup
-	log.Printf("Scheduler started with %d tasks", len(tasks))
+	log.Printf("Scheduler started with %d tasks", len(reg.list()))
 
 	for {
 		select {
 		case t := <-ticker.C:
 			log.Printf("Running cron tasks at %s", t.Format(time.RFC3339))
-			runCronTasks(tasks, t)
+			runCronTasks(reg.list(), t)
+			health.beat(t)
+		case t := <-heartbeat.C:
+			health.beat(t)
+		case <-stop:
+			return
 		}
 	}
 }
 
+// stringList is a flag that can be given several times.
+type stringList []string
+
+// String returns the values of the flag.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a value of the flag.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runSubcommand runs a command-line subcommand and returns its exit code.
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "healthcheck":
+		return runHealthcheck(args)
+	case "ctl":
+		return runCtl(args)
+	case "history":
+		return runHistoryCommand(args)
+	case "validate":
+		return runValidate(args)
+	case "next":
+		return runNext(args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+		return 2
+	}
+}
+
 // main initializes and runs the cron scheduler.
 // Creates separate tickers for @every tasks and a main ticker for standard cron tasks.
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
+	configPath := flag.String("config", os.Getenv("GRON_CONFIG"), "path to a YAML or JSON configuration file")
+	var crontabs stringList
+	flag.Var(&crontabs, "f", "crontab file or cron.d directory to load (repeatable)")
+	flag.Parse()
+
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	// Listen for both SIGINT (Ctrl+C) and SIGTERM (docker stop)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGKILL)
 
-	tasks := loadTasks()
+	// Load tasks and notification targets from the environment and config file
+	config, err := loadConfiguration(*configPath, crontabs, os.Environ())
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	strict, err := strictFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(config.errors) > 0 {
+		if strict {
+			for _, err := range config.errors {
+				log.Printf("Invalid task: %v", err)
+			}
+			log.Fatalf("%d tasks failed to load; fix them or set GRON_STRICT=false to skip them", len(config.errors))
+		}
+		log.Printf("Warning: Skipping %d invalid tasks", len(config.errors))
+	}
+	tasks := config.tasks
+	health.setLoaded(len(tasks), config.errors)
+	registry.set(tasks)
 
 	// If no tasks are loaded, log a warning but don't exit
 	if len(tasks) == 0 {
-		log.Printf("Warning: No tasks loaded from environment variables")
+		log.Printf("Warning: No tasks loaded")
+	}
+
+	// Open the run history store
+	store, err := historyStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to open run history: %v", err)
+	}
+	runHistory = store
+
+	// Open the store of completed @at tasks
+	if completions, err = completionStoreFromEnv(); err != nil {
+		log.Fatalf("Failed to open task state: %v", err)
+	}
+	for _, task := range tasks {
+		if task.isAt && !completions.persistent() {
+			log.Printf("Warning: @at tasks may run again after a restart, set GRON_STATE_FILE to remember them")
+			break
+		}
+	}
+
+	// Start sending notifications and mail reports
+	setNotifiers(config.webhooks, config.mailer)
+
+	// Start the health endpoints if requested
+	if addr := os.Getenv("GRON_HEALTH_ADDR"); addr != "" {
+		if maxAge := os.Getenv("GRON_HEALTH_MAX_AGE"); maxAge != "" {
+			d, err := time.ParseDuration(maxAge)
+			if err != nil || d <= 0 {
+				log.Fatalf("Invalid GRON_HEALTH_MAX_AGE '%s'", maxAge)
+			}
+			health.maxAge = d
+		}
+		if _, err := startHealthServer(addr, health); err != nil {
+			log.Fatalf("Failed to start health endpoints on %s: %v", addr, err)
+		}
+	}
+
+	// Start the admin API if enabled
+	if os.Getenv("GRON_API_ENABLED") == "true" {
+		addr := os.Getenv("GRON_API_ADDR")
+		if addr == "" {
+			addr = defaultAPIAddr
+		}
+		if _, err := startAdminServer(addr, registry, os.Getenv("GRON_API_TOKEN")); err != nil {
+			log.Fatalf("Failed to start admin API on %s: %v", addr, err)
+		}
+	}
+
+	// Start the control socket unless disabled
+	socketPath := controlSocketPath()
+	if socketPath != "" {
+		if _, err := startControlSocket(socketPath, registry); err != nil {
+			log.Printf("Failed to start control socket on %s: %v", socketPath, err)
+			socketPath = ""
+		}
+	}
+
+	// Reload the tasks on SIGHUP and, if requested, when the files change.
+	// Reloads wait until the scheduler has set up the initial tasks, so that
+	// they cannot start tickers the scheduler starts again.
+	reloads := &reloader{path: *configPath, crontabs: crontabs, environ: os.Environ(), registry: registry}
+	started := make(chan struct{})
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		<-started
+		for range hupChan {
+			log.Printf("Received SIGHUP, reloading")
+			if err := reloads.reload(); err != nil {
+				log.Printf("Rejected reload: %v", err)
+			}
+		}
+	}()
+	if v := os.Getenv("GRON_WATCH_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid GRON_WATCH_INTERVAL '%s'", v)
+		}
+		go func() {
+			<-started
+			reloads.watch(interval)
+		}()
 	}
 
 	// Start scheduler in a goroutine
@@ -386,11 +1146,11 @@ func main() {
 				log.Printf("Recovered from panic in scheduler: %v", r)
 			}
 		}()
-		startCronScheduler(tasks)
+		startCronScheduler(registry, started, nil)
 		// This should never happen, but if it does, log it
 		log.Printf("Scheduler completed unexpectedly, restarting...")
 		// Restart the scheduler if it exits unexpectedly
-		go startCronScheduler(tasks)
+		go startCronScheduler(registry, nil, nil)
 	}()
 
 	// Create a channel for graceful exit
@@ -406,6 +1166,11 @@ func main() {
 
 	// Block until done
 	<-done
+	// Send mail reports still waiting for the next batch.
+	setNotifiers(nil, nil)
+	if socketPath != "" {
+		os.Remove(socketPath)
+	}
 	// Exit with success code
 	os.Exit(0)
 }
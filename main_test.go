@@ -31,6 +31,31 @@ func TestParseField(t *testing.T) {
 		{"specific_day_of_week", "0", CronField{0, 6}, []int{0}, false},
 		{"invalid_day_of_week", "8", CronField{0, 6}, nil, true},
 		{"smaller_step", "*/5", CronField{0, 59}, []int{0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55}, false},
+		{"range", "1-5", CronField{0, 59}, []int{1, 2, 3, 4, 5}, false},
+		{"range_at_limits", "0-59", CronField{0, 59}, makeRange(0, 59), false},
+		{"range_with_step", "0-20/10", CronField{0, 59}, []int{0, 10, 20}, false},
+		{"start_with_step", "50/5", CronField{0, 59}, []int{50, 55}, false},
+		{"step_from_max", "59/10", CronField{0, 59}, []int{59}, false},
+		{"step_beyond_range", "*/60", CronField{0, 59}, []int{0}, false},
+		{"list", "5,1,3", CronField{0, 59}, []int{1, 3, 5}, false},
+		{"list_of_ranges", "10-11,1-2", CronField{0, 59}, []int{1, 2, 10, 11}, false},
+		{"overlapping_list", "1-5,3,5-6", CronField{0, 59}, []int{1, 2, 3, 4, 5, 6}, false},
+		{"month_limits", "1-12", CronField{1, 12}, makeRange(1, 12), false},
+		{"below_min", "0", CronField{1, 12}, nil, true},
+		{"range_above_max", "50-60", CronField{0, 59}, nil, true},
+		{"reversed_range", "5-1", CronField{0, 59}, nil, true},
+		{"zero_step", "*/0", CronField{0, 59}, nil, true},
+		{"empty_list_item", "1,,2", CronField{0, 59}, nil, true},
+		{"sunday_range", "5-7", CronField{0, 6}, []int{5, 6, 7}, false},
+		{"month_names", "jan-mar", CronField{1, 12}, []int{1, 2, 3}, false},
+		{"month_name_case", "DEC,Jun", CronField{1, 12}, []int{6, 12}, false},
+		{"day_names", "mon-fri", CronField{0, 6}, []int{1, 2, 3, 4, 5}, false},
+		{"day_range_to_sunday", "fri-sun", CronField{0, 6}, []int{5, 6, 7}, false},
+		{"day_name_list", "Sat,sun", CronField{0, 6}, []int{0, 6}, false},
+		{"day_name_step", "mon-sat/2", CronField{0, 6}, []int{1, 3, 5}, false},
+		{"day_name_in_months", "mon", CronField{1, 12}, nil, true},
+		{"name_in_minutes", "jan", CronField{0, 59}, nil, true},
+		{"unknown_name", "monday", CronField{0, 6}, nil, true},
 	}
 
 	for _, tt := range tests {
@@ -242,11 +267,17 @@ func TestParseEveryFormat(t *testing.T) {
 		{"seconds", "@every 30s", 30 * time.Second, false, true},
 		{"combined_time", "@every 1h30m", 90 * time.Minute, false, true},
 		{"invalid_format", "@every abc", 0, true, false},
-		{"invalid_day_combo", "@every 1d2h", 0, true, false},
+		{"day_combo", "@every 1d2h", 26 * time.Hour, false, true},
 		{"mixed_units", "@every 1h45m30s", time.Hour + 45*time.Minute + 30*time.Second, false, true},
-		// Go u043fu0430u0440u0441u0438u0442 u044du0442u0438 u0437u043du0430u0447u0435u043du0438u044f u043au043eu0440u0440u0435u043au0442u043du043e, u043fu043eu044du0442u043eu043cu0443 u043eu043du0438 u043du0435 u0432u044bu0437u044bu0432u0430u044eu0442 u043eu0448u0438u0431u043au0438
-		{"zero_days", "@every 0d", 0, false, true},
-		{"negative_time", "@every -1h", -time.Hour, false, true},
+		{"weeks", "@every 2w", 14 * 24 * time.Hour, false, true},
+		{"weeks_and_days", "@every 1w2d", 9 * 24 * time.Hour, false, true},
+		{"fractional_days", "@every 1.5d", 36 * time.Hour, false, true},
+		{"zero_days", "@every 0d", 0, true, false},
+		{"zero_seconds", "@every 0s", 0, true, false},
+		{"negative_time", "@every -1h", 0, true, false},
+		{"below_minimum", "@every 500ms", 0, true, false},
+		{"missing_unit", "@every 10", 0, true, false},
+		{"unknown_unit", "@every 1y", 0, true, false},
 	}
 
 	for _, tt := range tests {
@@ -791,3 +822,215 @@ func createTestSchedule(minutes, hours, days, months, weekdays []int) *CronSched
 }
 
 // TestHelperProcess используется для тестирования executeCommand
+
+// TestParseTasksErrors verifies that invalid tasks are reported as errors
+func TestParseTasksErrors(t *testing.T) {
+	environ := []string{
+		"TASK_OK=* * * * * echo ok",
+		"TASK_SHORT=invalid",
+		"TASK_BAD_CRON=61 * * * * echo bad",
+		"TASK_FEW_FIELDS=* * * echo few",
+		"OTHER=ignored",
+	}
+
+	tasks, errs := parseTasks(environ)
+	if len(tasks) != 1 {
+		t.Errorf("expected 1 task, got %d", len(tasks))
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "TASK_SHORT") {
+		t.Errorf("expected error to name the variable, got %v", errs[0])
+	}
+}
+
+// TestNext verifies calculation of the next fire time
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		from       time.Time
+		expected   time.Time
+	}{
+		{"every_minute", "* * * * *", time.Date(2025, 1, 1, 12, 15, 30, 0, time.UTC), time.Date(2025, 1, 1, 12, 16, 0, 0, time.UTC)},
+		{"strictly_after", "15 12 * * *", time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC), time.Date(2025, 1, 2, 12, 15, 0, 0, time.UTC)},
+		{"next_hour", "0 * * * *", time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC), time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"month_end", "0 0 1 * *", time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"year_end", "0 0 1 1 *", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"weekday", "30 9 * * 1", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 6, 9, 30, 0, 0, time.UTC)},
+		{"leap_day", "0 0 29 2 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 31 2 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCronSchedule(tt.expression)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tt.expression, err)
+			}
+			if got := schedule.next(tt.from); !got.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseTasksNames verifies tasks are named after their variables and sorted
+func TestParseTasksNames(t *testing.T) {
+	environ := []string{
+		"TASK_REPORT=@daily report.sh",
+		"TASK_BACKUP_DB=0 2 * * * backup.sh",
+		"TASK_CLEANUP=@hourly backup.sh",
+		"TASK_Backup_DB=@hourly other.sh",
+		"TASK_=@hourly unnamed.sh",
+	}
+
+	tasks, errs := parseTasks(environ)
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.name)
+	}
+	if strings.Join(names, ",") != "backup_db,cleanup,report" {
+		t.Errorf("expected sorted task names, got %v", names)
+	}
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "already defined by TASK_BACKUP_DB") {
+		t.Errorf("expected duplicate and invalid name errors, got %v", errs)
+	}
+}
+
+// TestParseTasksCompanions verifies per-task options from companion variables
+func TestParseTasksCompanions(t *testing.T) {
+	environ := []string{
+		"TASK_BACKUP=0 2 * * * backup.sh",
+		"TASK_BACKUP_TIMEOUT=1h",
+		"TASK_BACKUP_RETRIES=2",
+		"TASK_BACKUP_RETRY_DELAY=30s",
+		"TASK_BACKUP_DIR=/backup",
+		"TASK_BACKUP_USER=postgres",
+		"TASK_BACKUP_START_DELAY=5m",
+		"TASK_BACKUP_DB=@daily dump.sh",
+		"TASK_BACKUP_DB_TIMEOUT=10m",
+		"TASK_OLD=@hourly old.sh",
+		"TASK_OLD_ENABLED=false",
+	}
+
+	tasks, errs := parseTasks(environ)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected backup and backup_db, got %d tasks", len(tasks))
+	}
+	backup, db := tasks[0], tasks[1]
+	if backup.exec.timeout != time.Hour || backup.retries != 2 || backup.retryDelay != 30*time.Second {
+		t.Errorf("unexpected backup options: %+v, %d, %v", backup.exec, backup.retries, backup.retryDelay)
+	}
+	if backup.exec.dir != "/backup" || backup.exec.user != "postgres" || backup.startDelay != 5*time.Minute {
+		t.Errorf("unexpected backup options: %+v, %v", backup.exec, backup.startDelay)
+	}
+	if db.name != "backup_db" || db.exec.timeout != 10*time.Minute {
+		t.Errorf("unexpected backup_db task: %s, %+v", db.name, db.exec)
+	}
+
+	// A variable ending in an option whose value is a task definition is a task
+	tasks, errs = parseTasks([]string{"TASK_DB=@daily db.sh", "TASK_DB_USER=0 * * * * users.sh", "TASK_DB_TIMEOUT=1h"})
+	if len(errs) != 0 || len(tasks) != 2 {
+		t.Fatalf("expected db and db_user, got %d tasks, errors %v", len(tasks), errs)
+	}
+	if db, users := tasks[0], tasks[1]; db.exec.user != "" || db.exec.timeout != time.Hour || users.name != "db_user" || users.command != "users.sh" {
+		t.Errorf("unexpected tasks: %s %+v, %s %q", db.name, db.exec, users.name, users.command)
+	}
+
+	invalid := []struct {
+		name     string
+		environ  []string
+		expected string
+	}{
+		{"unknown_option", []string{"TASK_BACKUP=@daily b.sh", "TASK_BACKUP_TIMOUT=1h"}, "unknown option TIMOUT for TASK_BACKUP"},
+		{"bad_retries", []string{"TASK_BACKUP=@daily b.sh", "TASK_BACKUP_RETRIES=many"}, "TASK_BACKUP_RETRIES: invalid retries"},
+		{"bad_enabled", []string{"TASK_BACKUP=@daily b.sh", "TASK_BACKUP_ENABLED=maybe"}, "TASK_BACKUP_ENABLED: invalid boolean"},
+		{"bad_timeout", []string{"TASK_BACKUP=@daily b.sh", "TASK_BACKUP_TIMEOUT=soon"}, "TASK_BACKUP_TIMEOUT: invalid timeout"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := parseTasks(tt.environ)
+			if len(errs) == 0 || !strings.Contains(errs[0].Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, errs)
+			}
+		})
+	}
+}
+
+// TestRunStartTasks verifies @reboot tasks only run at boot and run-on-start tasks whenever they are started
+func TestRunStartTasks(t *testing.T) {
+	useTestHistory(t)
+	originalRunner := defaultCommandRunner
+	setCommandRunner(&MockCommandRunner{})
+	defer setCommandRunner(originalRunner)
+
+	reboot, _ := parseSchedule("@reboot")
+	reboot.name = "reboot"
+	warm, _ := parseSchedule("@every 1d")
+	warm.name = "warm"
+	warm.runOnStart = true
+	plain, _ := parseSchedule("@hourly")
+	plain.name = "plain"
+	tasks := []*CronSchedule{reboot, warm, plain}
+
+	if !reboot.next(time.Now()).IsZero() {
+		t.Error("expected @reboot to have no next run")
+	}
+
+	waitRuns := func(name string, n int) []runRecord {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			runs, _ := runHistory.list(name, 0)
+			if len(runs) >= n || time.Now().After(deadline) {
+				return runs
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	runStartTasks(tasks, true)
+	if runs := waitRuns("reboot", 1); len(runs) != 1 || runs[0].Trigger != triggerStartup {
+		t.Errorf("expected one startup run of reboot, got %+v", runs)
+	}
+	if runs := waitRuns("warm", 1); len(runs) != 1 {
+		t.Errorf("expected one run of warm, got %d", len(runs))
+	}
+
+	runStartTasks(tasks, false)
+	if runs := waitRuns("warm", 2); len(runs) != 2 {
+		t.Errorf("expected warm to run again, got %d runs", len(runs))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if runs, _ := runHistory.list("reboot", 0); len(runs) != 1 {
+		t.Errorf("expected reboot to run only at boot, got %d runs", len(runs))
+	}
+	if runs, _ := runHistory.list("plain", 0); len(runs) != 0 {
+		t.Errorf("expected plain not to run, got %d runs", len(runs))
+	}
+}
+
+// TestEveryInitialDelay verifies the first tick of an @every task comes after its initial delay
+func TestEveryInitialDelay(t *testing.T) {
+	task, _ := parseSchedule("@every 1h")
+	task.name = "warm"
+	task.initialDelay = 5 * time.Minute
+
+	start := time.Now()
+	ticker := startEveryTicker(task)
+	defer ticker.Stop()
+	defer task.state.stopTicker()
+
+	next := task.next(start)
+	if d := next.Sub(start); d < 5*time.Minute || d > 5*time.Minute+time.Second {
+		t.Errorf("expected the first run after 5m, got %v", d)
+	}
+	if d := task.next(next).Sub(next); d != time.Hour {
+		t.Errorf("expected later runs an hour apart, got %v", d)
+	}
+}
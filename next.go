@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// nextTimeLayout shows the weekday and the UTC offset of fire times, so that
+// DST changes are visible.
+const nextTimeLayout = "Mon 2006-01-02 15:04:05 -07:00 MST"
+
+// upcoming returns the next n times after from at which the schedule fires.
+// Unaligned @every schedules are counted from from, as if gron started then. Fewer
+// times are returned if the schedule stops matching.
+func (s *CronSchedule) upcoming(from time.Time, n int) []time.Time {
+	var times []time.Time
+	if s.isEvery && !s.aligned {
+		first := time.Duration(1)
+		if s.notBefore.After(from) {
+			// The first tick at or after the start of the task.
+			first = (s.notBefore.Sub(from) + s.interval - 1) / s.interval
+		}
+		// Each skip passes at least a closed window or the rest of a day.
+		skips := 0
+		for i := first; len(times) < n; i++ {
+			t := from.Add(i * s.interval)
+			if !s.activeAt(t) {
+				break
+			}
+			if !s.inWindow(t) {
+				open := s.nextWindowOpen(t)
+				if skips++; open.IsZero() || skips > maxCalendarDays {
+					break
+				}
+				// Continue before the first tick in the window.
+				i = (open.Sub(from)+s.interval-1)/s.interval - 1
+				continue
+			}
+			if s.calendarBlock(t) != "" {
+				if skips++; skips > maxCalendarDays {
+					break
+				}
+				// Continue after the last tick of the day ruled out.
+				i = s.endOfDay(t).Sub(from) / s.interval
+				continue
+			}
+			times = append(times, t)
+		}
+		return times
+	}
+	for i := 1; i <= n; i++ {
+		t := s.next(from)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+		from = t
+	}
+	return times
+}
+
+// runNext runs the "next" command with the process arguments and standard
+// streams.
+func runNext(args []string) int {
+	return nextCommand(args, os.Environ(), time.Now(), os.Stdout, os.Stderr)
+}
+
+// nextCommand prints the next fire times of a schedule expression given as
+// arguments or, without arguments, of every configured task. Schedules
+// without a time zone of their own are evaluated in the time zone given by
+// -tz, which defaults to the local one, and all times are shown in it.
+func nextCommand(args, environ []string, now time.Time, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("next", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv("GRON_CONFIG"), "path to a YAML or JSON configuration file")
+	var crontabs stringList
+	fs.Var(&crontabs, "f", "crontab file or cron.d directory to load (repeatable)")
+	count := fs.Int("n", 5, "number of fire times to show")
+	zone := fs.String("tz", "", "time zone to evaluate and show times in (defaults to local time)")
+	from := fs.String("from", "", "RFC 3339 time to start from (defaults to now)")
+	name := fs.String("name", "", "task name that H tokens of the expression are derived from")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *count <= 0 {
+		fmt.Fprintln(stderr, "next: -n must be positive")
+		return 2
+	}
+
+	loc := time.Local
+	if *zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(*zone); err != nil {
+			fmt.Fprintf(stderr, "next: invalid time zone '%s': %v\n", *zone, err)
+			return 2
+		}
+	}
+	if *from != "" {
+		var err error
+		if now, err = time.Parse(time.RFC3339, *from); err != nil {
+			fmt.Fprintf(stderr, "next: invalid start time '%s', expected RFC 3339\n", *from)
+			return 2
+		}
+	}
+	now = now.In(loc)
+
+	// An expression on the command line
+	if fs.NArg() > 0 {
+		var fileAliases map[string]string
+		if *configPath != "" {
+			cfg, err := readConfigFile(*configPath)
+			if err != nil {
+				fmt.Fprintf(stderr, "next: %s: %v\n", *configPath, err)
+				return 1
+			}
+			fileAliases = cfg.Aliases
+		}
+		aliases, err := loadAliases(environ, fileAliases)
+		if err != nil {
+			fmt.Fprintf(stderr, "next: %v\n", err)
+			return 1
+		}
+		task, err := parseScheduleFor(expandAlias(strings.Join(fs.Args(), " "), aliases), *name)
+		if err != nil {
+			fmt.Fprintf(stderr, "next: %v\n", err)
+			return 1
+		}
+		if task.location == nil {
+			task.location = loc
+		}
+		fmt.Fprintf(stdout, "Runs %s:\n", task.describe())
+		printUpcoming(stdout, task.upcoming(now, *count), loc, "  ")
+		return 0
+	}
+
+	// Loading logs every task; only the result is of interest here.
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard)
+	config, err := loadConfiguration(*configPath, crontabs, environ)
+	log.SetOutput(logOutput)
+	if err != nil {
+		fmt.Fprintf(stderr, "next: %v\n", err)
+		return 1
+	}
+
+	for i, task := range config.tasks {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		if task.location == nil {
+			task.location = loc
+		}
+		fmt.Fprintf(stdout, "%s (%s): %s\n", task.name, task.spec, task.describe())
+		printUpcoming(stdout, task.upcoming(now, *count), loc, "  ")
+	}
+	for _, err := range config.errors {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+	}
+	if len(config.errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printUpcoming prints fire times in the given time zone, one per line.
+func printUpcoming(w io.Writer, times []time.Time, loc *time.Location, indent string) {
+	if len(times) == 0 {
+		fmt.Fprintf(w, "%snever\n", indent)
+	}
+	for _, t := range times {
+		fmt.Fprintf(w, "%s%s\n", indent, t.In(loc).Format(nextTimeLayout))
+	}
+}
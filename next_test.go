@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUpcoming verifies fire times across month ends and DST changes
+func TestUpcoming(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		loc      *time.Location
+		from     string
+		expected []string
+	}{
+		{
+			name:     "month_end",
+			spec:     "0 0 31 * *",
+			loc:      time.UTC,
+			from:     "2026-01-01T00:00:00Z",
+			expected: []string{"2026-01-31T00:00:00Z", "2026-03-31T00:00:00Z", "2026-05-31T00:00:00Z"},
+		},
+		{
+			name:     "leap_day",
+			spec:     "0 12 29 2 *",
+			loc:      time.UTC,
+			from:     "2026-01-01T00:00:00Z",
+			expected: []string{"2028-02-29T12:00:00Z", "2032-02-29T12:00:00Z"},
+		},
+		{
+			name:     "spring_forward_skips_missing_time",
+			spec:     "30 2 * * *",
+			loc:      berlin,
+			from:     "2026-03-28T00:00:00Z",
+			expected: []string{"2026-03-28T02:30:00+01:00", "2026-03-30T02:30:00+02:00"},
+		},
+		{
+			name:     "fall_back_repeats_hour",
+			spec:     "30 2 * * *",
+			loc:      berlin,
+			from:     "2026-10-25T00:00:00+02:00",
+			expected: []string{"2026-10-25T02:30:00+02:00", "2026-10-25T02:30:00+01:00", "2026-10-26T02:30:00+01:00"},
+		},
+		{
+			name:     "hourly_across_fall_back",
+			spec:     "0 * * * *",
+			loc:      berlin,
+			from:     "2026-10-25T01:30:00+02:00",
+			expected: []string{"2026-10-25T02:00:00+02:00", "2026-10-25T02:00:00+01:00", "2026-10-25T03:00:00+01:00"},
+		},
+		{
+			name:     "every",
+			spec:     "@every 90m",
+			loc:      time.UTC,
+			from:     "2026-01-01T00:00:00Z",
+			expected: []string{"2026-01-01T01:30:00Z", "2026-01-01T03:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			task.location = tt.loc
+			from, _ := time.Parse(time.RFC3339, tt.from)
+
+			times := task.upcoming(from, len(tt.expected))
+			if len(times) != len(tt.expected) {
+				t.Fatalf("expected %d times, got %v", len(tt.expected), times)
+			}
+			for i, want := range tt.expected {
+				if got := times[i].In(tt.loc).Format(time.RFC3339); got != want {
+					t.Errorf("time %d: expected %s, got %s", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestNextCommand verifies the output of gron next for expressions and tasks
+func TestNextCommand(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var stdout, stderr bytes.Buffer
+
+	code := nextCommand([]string{"-n", "2", "-tz", "UTC", "0", "9", "*", "*", "1-5"}, nil, now, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected := "Runs at 09:00, Monday through Friday:\n  Thu 2026-01-01 09:00:00 +00:00 UTC\n  Fri 2026-01-02 09:00:00 +00:00 UTC\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	environ := []string{"TASK_REPORT=@monthly report.sh", "TASK_BERLIN=0 8 * * * report.sh", "TASK_BERLIN_TIMEZONE=Europe/Berlin"}
+	if code := nextCommand([]string{"-n", "1", "-tz", "UTC"}, environ, now, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected = "berlin (0 8 * * *): at 08:00, every day\n  Thu 2026-01-01 07:00:00 +00:00 UTC\n\nreport (@monthly): at 00:00, on day 1 of the month\n  Sun 2026-02-01 00:00:00 +00:00 UTC\n"
+	if stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+
+	stderr.Reset()
+	if code := nextCommand([]string{"61", "*", "*", "*", "*"}, nil, now, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "field 1 (minute)") {
+		t.Errorf("expected the invalid field to be reported, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Notification events.
+const (
+	eventSuccess  = "success"
+	eventFailure  = "failure"
+	eventSkipped  = "skipped"
+	eventRecovery = "recovery"
+)
+
+// notification describes a run outcome sent to notification targets.
+type notification struct {
+	Event           string    `json:"event"`
+	Task            string    `json:"task"`
+	Schedule        string    `json:"schedule"`
+	Command         string    `json:"command"`
+	RunID           string    `json:"run_id,omitempty"`
+	Trigger         string    `json:"trigger,omitempty"`
+	ExitCode        int       `json:"exit_code"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Error           string    `json:"error,omitempty"`
+	Output          string    `json:"output,omitempty"`
+}
+
+// Duration returns the run duration rounded for display.
+func (n notification) Duration() time.Duration {
+	return n.End.Sub(n.Start).Round(time.Millisecond)
+}
+
+// newNotification builds a notification for a run of a task.
+func newNotification(event string, task *CronSchedule, record runRecord) notification {
+	return notification{
+		Event:           event,
+		Task:            task.name,
+		Schedule:        task.spec,
+		Command:         task.command,
+		RunID:           record.ID,
+		Trigger:         record.Trigger,
+		ExitCode:        record.ExitCode,
+		Start:           record.Start,
+		End:             record.End,
+		DurationSeconds: record.Duration().Seconds(),
+		Error:           record.Error,
+		Output:          record.Output,
+	}
+}
+
+// runEvent classifies a finished run, taking the previous run into account to
+// detect recoveries.
+func runEvent(record runRecord, previous *runRecord) string {
+	if record.ExitCode != 0 {
+		return eventFailure
+	}
+	if previous != nil && previous.ExitCode != 0 {
+		return eventRecovery
+	}
+	return eventSuccess
+}
+
+// Built-in webhook body templates compatible with common chat services.
+var webhookTemplates = map[string]string{
+	"slack": `{"text": {{printf "%s gron task *%s* %s (exit %d, %v)" (emoji .Event) .Task .Event .ExitCode .Duration | json}}` +
+		`{{if .Output}}, "attachments": [{"text": {{printf "` + "```%s```" + `" .Output | json}}}]{{end}}}`,
+	"teams": `{"@type": "MessageCard", "@context": "https://schema.org/extensions", ` +
+		`"themeColor": {{color .Event | json}}, "summary": {{printf "gron task %s %s" .Task .Event | json}}, ` +
+		`"title": {{printf "gron task %s %s" .Task .Event | json}}, ` +
+		`"text": {{printf "Schedule: %s<br>Exit code: %d<br>Duration: %v<br><pre>%s</pre>" .Schedule .ExitCode .Duration .Output | json}}}`,
+}
+
+// webhookFuncs are the functions available to webhook templates.
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"emoji": func(event string) string {
+		switch event {
+		case eventFailure:
+			return ":x:"
+		case eventSkipped:
+			return ":warning:"
+		default:
+			return ":white_check_mark:"
+		}
+	},
+	"color": func(event string) string {
+		switch event {
+		case eventFailure:
+			return "D70000"
+		case eventSkipped:
+			return "FFA500"
+		default:
+			return "2EB886"
+		}
+	},
+}
+
+// webhookRetryDelay is the delay before the first retry of a failed delivery.
+// It doubles with every further attempt.
+var webhookRetryDelay = time.Second
+
+// webhookClient is the HTTP client used to deliver webhooks.
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookTarget is a URL that receives notifications for selected events.
+type webhookTarget struct {
+	url      string
+	events   map[string]bool
+	template *template.Template
+	retries  int
+}
+
+// newWebhookTarget creates a webhook target. events is a comma-separated list
+// of "failure", "skipped", "recovery" and "always"; format is "json", "slack"
+// or "teams" and is ignored if a custom body template is given.
+func newWebhookTarget(url, events, format, body string, retries int) (*webhookTarget, error) {
+	target := &webhookTarget{url: url, events: make(map[string]bool), retries: retries}
+
+	if url == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("webhook retries must not be negative, got %d", retries)
+	}
+
+	if events == "" {
+		events = eventFailure
+	}
+	for _, event := range strings.Split(events, ",") {
+		event = strings.TrimSpace(event)
+		switch event {
+		case eventFailure, eventSkipped, eventRecovery, "always":
+			target.events[event] = true
+		default:
+			return nil, fmt.Errorf("unknown webhook event '%s'", event)
+		}
+	}
+
+	if body == "" && format != "" && format != "json" {
+		var ok bool
+		if body, ok = webhookTemplates[format]; !ok {
+			return nil, fmt.Errorf("unknown webhook format '%s'", format)
+		}
+	}
+	if body != "" {
+		tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %v", err)
+		}
+		target.template = tmpl
+	}
+
+	return target, nil
+}
+
+// wants reports whether the target subscribed to the event.
+func (w *webhookTarget) wants(event string) bool {
+	return w.events["always"] || w.events[event]
+}
+
+// body renders the request body for a notification.
+func (w *webhookTarget) body(n notification) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(n)
+	}
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver posts a notification, retrying with exponential backoff on failure.
+func (w *webhookTarget) deliver(n notification) error {
+	body, err := w.body(n)
+	if err != nil {
+		return err
+	}
+
+	delay := webhookRetryDelay
+	for attempt := 0; ; attempt++ {
+		err = w.post(body)
+		if err == nil || attempt >= w.retries {
+			return err
+		}
+		log.Printf("Webhook delivery to %s failed, retrying in %v: %v", w.url, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends a single delivery attempt.
+func (w *webhookTarget) post(body []byte) error {
+	resp, err := webhookClient.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
+
+// globalWebhooks receive notifications for every task.
+var globalWebhooks []*webhookTarget
+
+// notifyMu guards globalWebhooks and defaultMailer, which are replaced when
+// the configuration is reloaded.
+var notifyMu sync.RWMutex
+
+// setNotifiers replaces the global webhooks and the mailer, stopping the
+// previous mailer after sending its queued reports.
+func setNotifiers(webhooks []*webhookTarget, mail *mailer) {
+	notifyMu.Lock()
+	previous := defaultMailer
+	globalWebhooks = webhooks
+	defaultMailer = mail
+	notifyMu.Unlock()
+
+	if previous != nil {
+		previous.stop()
+	}
+	if mail != nil {
+		go mail.run()
+	}
+}
+
+// notifyRun sends a notification to every global and per-task webhook that
+// subscribed to the event and queues a mail report if mail is configured.
+// Deliveries run in the background.
+func notifyRun(task *CronSchedule, event string, record runRecord) {
+	notifyMu.RLock()
+	mail, webhooks := defaultMailer, globalWebhooks
+	notifyMu.RUnlock()
+
+	if mail != nil && event != eventSkipped {
+		mail.notify(task, newNotification(event, task, record), record)
+	}
+
+	targets := append(append([]*webhookTarget(nil), webhooks...), task.webhooks...)
+	for _, target := range targets {
+		if !target.wants(event) {
+			continue
+		}
+		go func(t *webhookTarget, n notification) {
+			if err := t.deliver(n); err != nil {
+				log.Printf("Failed to deliver %s notification for task '%s' to %s: %v", n.Event, n.Task, t.url, err)
+			}
+		}(target, newNotification(event, task, record))
+	}
+}
+
+// webhookOptions are the per-target settings read from the environment.
+var webhookOptions = []string{"URL", "EVENTS", "FORMAT", "TEMPLATE", "RETRIES"}
+
+// scopedSettings collects settings named <prefix><OPTION> (global, returned
+// under the empty name) and <prefix><TASK>_<OPTION> (per task, returned under
+// the lowercased task name) from a list of "KEY=value" environment entries.
+func scopedSettings(environ []string, prefix string, options []string) (map[string]map[string]string, error) {
+	settings := make(map[string]map[string]string)
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(parts[0], prefix)
+
+		name, option := "", ""
+		for _, opt := range options {
+			if rest == opt {
+				option = opt
+				break
+			}
+			if strings.HasSuffix(rest, "_"+opt) {
+				name, option = strings.ToLower(strings.TrimSuffix(rest, "_"+opt)), opt
+				break
+			}
+		}
+		if option == "" {
+			return nil, fmt.Errorf("%s: unknown option, expected one of %s", parts[0], strings.Join(options, ", "))
+		}
+
+		if settings[name] == nil {
+			settings[name] = make(map[string]string)
+		}
+		settings[name][option] = parts[1]
+	}
+	return settings, nil
+}
+
+// sortedNames returns the keys of settings in a stable order, global first.
+func sortedNames(settings map[string]map[string]string) []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadWebhooks configures webhook targets from environment variables:
+// GRON_WEBHOOK_<OPTION> for the global target and GRON_WEBHOOK_<TASK>_<OPTION>
+// for a target of a single task, where OPTION is one of URL, EVENTS, FORMAT,
+// TEMPLATE and RETRIES. Per-task targets are attached to the matching tasks.
+func loadWebhooks(environ []string, tasks []*CronSchedule) ([]*webhookTarget, error) {
+	settings, err := scopedSettings(environ, "GRON_WEBHOOK_", webhookOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*CronSchedule)
+	for _, task := range tasks {
+		byName[task.name] = task
+	}
+
+	var global []*webhookTarget
+	for _, name := range sortedNames(settings) {
+		opts := settings[name]
+		prefix := "GRON_WEBHOOK_"
+		if name != "" {
+			prefix += strings.ToUpper(name) + "_"
+		}
+
+		retries := 3
+		if v := opts["RETRIES"]; v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%sRETRIES: %v", prefix, err)
+			}
+			retries = n
+		}
+
+		target, err := newWebhookTarget(opts["URL"], opts["EVENTS"], opts["FORMAT"], opts["TEMPLATE"], retries)
+		if err != nil {
+			return nil, fmt.Errorf("%sURL: %v", prefix, err)
+		}
+
+		if name == "" {
+			global = append(global, target)
+			continue
+		}
+		task, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%sURL: unknown task '%s'", prefix, name)
+		}
+		task.webhooks = append(task.webhooks, target)
+	}
+
+	return global, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errTestCommand is returned by mocked commands that fail
+var errTestCommand = fmt.Errorf("mock command failure")
+
+// webhookStub is a local HTTP server recording webhook deliveries
+type webhookStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   []string
+	failures int // number of requests to reject before accepting
+}
+
+// newWebhookStub starts a webhook stub that rejects the first failures requests
+func newWebhookStub(t *testing.T, failures int) *webhookStub {
+	stub := &webhookStub{failures: failures}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		if stub.failures > 0 {
+			stub.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		stub.bodies = append(stub.bodies, string(body))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// received waits until count deliveries arrived and returns them
+func (s *webhookStub) received(t *testing.T, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		n := len(s.bodies)
+		s.mu.Unlock()
+		if n >= count {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+// TestRunEvent verifies classification of run outcomes
+func TestRunEvent(t *testing.T) {
+	failed := &runRecord{ExitCode: 1}
+	succeeded := &runRecord{ExitCode: 0}
+
+	tests := []struct {
+		name     string
+		record   runRecord
+		previous *runRecord
+		expected string
+	}{
+		{"first_success", runRecord{ExitCode: 0}, nil, eventSuccess},
+		{"first_failure", runRecord{ExitCode: 2}, nil, eventFailure},
+		{"repeated_failure", runRecord{ExitCode: 1}, failed, eventFailure},
+		{"recovery", runRecord{ExitCode: 0}, failed, eventRecovery},
+		{"repeated_success", runRecord{ExitCode: 0}, succeeded, eventSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runEvent(tt.record, tt.previous); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestWebhookTargetWants verifies event subscriptions
+func TestWebhookTargetWants(t *testing.T) {
+	tests := []struct {
+		events   string
+		event    string
+		expected bool
+	}{
+		{"", eventFailure, true},
+		{"", eventSuccess, false},
+		{"failure,recovery", eventRecovery, true},
+		{"failure,recovery", eventSkipped, false},
+		{"always", eventSuccess, true},
+	}
+
+	for _, tt := range tests {
+		target, err := newWebhookTarget("http://example.invalid", tt.events, "", "", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := target.wants(tt.event); got != tt.expected {
+			t.Errorf("events %q, event %s: expected %v, got %v", tt.events, tt.event, tt.expected, got)
+		}
+	}
+
+	if _, err := newWebhookTarget("http://example.invalid", "sometimes", "", "", 0); err == nil {
+		t.Error("expected error for unknown event")
+	}
+	if _, err := newWebhookTarget("http://example.invalid", "", "irc", "", 0); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// TestWebhookFormats verifies built-in and custom bodies are valid JSON
+func TestWebhookFormats(t *testing.T) {
+	n := notification{
+		Event:    eventFailure,
+		Task:     "backup",
+		Schedule: "0 2 * * *",
+		ExitCode: 1,
+		Start:    time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 1, 1, 2, 0, 3, 0, time.UTC),
+		Output:   "disk \"full\"\n",
+	}
+
+	tests := []struct {
+		format   string
+		body     string
+		expected string
+	}{
+		{"json", "", `"task":"backup"`},
+		{"slack", "", `gron task *backup* failure (exit 1, 3s)`},
+		{"teams", "", `"@type":"MessageCard"`},
+		{"", `{"msg": {{printf "%s failed" .Task | json}}}`, `"msg":"backup failed"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			target, err := newWebhookTarget("http://example.invalid", "", tt.format, tt.body, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			body, err := target.body(n)
+			if err != nil {
+				t.Fatalf("failed to render body: %v", err)
+			}
+
+			var decoded interface{}
+			if err := json.Unmarshal(body, &decoded); err != nil {
+				t.Fatalf("body is not valid JSON: %v\n%s", err, body)
+			}
+			compact, _ := json.Marshal(decoded)
+			if !strings.Contains(string(compact), tt.expected) {
+				t.Errorf("expected body to contain %s, got %s", tt.expected, compact)
+			}
+		})
+	}
+}
+
+// TestWebhookDeliveryRetry verifies failed deliveries are retried
+func TestWebhookDeliveryRetry(t *testing.T) {
+	originalDelay := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = originalDelay }()
+
+	stub := newWebhookStub(t, 2)
+	target, _ := newWebhookTarget(stub.URL, "", "", "", 2)
+	if err := target.deliver(notification{Task: "backup"}); err != nil {
+		t.Fatalf("expected delivery to succeed after retries, got %v", err)
+	}
+	if bodies := stub.received(t, 1); len(bodies) != 1 {
+		t.Errorf("expected 1 delivery, got %d", len(bodies))
+	}
+
+	failing := newWebhookStub(t, 10)
+	target, _ = newWebhookTarget(failing.URL, "", "", "", 1)
+	if err := target.deliver(notification{Task: "backup"}); err == nil {
+		t.Error("expected delivery to fail after exhausting retries")
+	}
+}
+
+// TestRunTaskNotifications verifies runTask notifies on failure and recovery
+func TestRunTaskNotifications(t *testing.T) {
+	mockRunner := &MockCommandRunner{ReturnError: errTestCommand, ReturnOutput: []byte("boom")}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+	useTestHistory(t)
+
+	stub := newWebhookStub(t, 0)
+	task := newTestRegistry().get("backup")
+	target, _ := newWebhookTarget(stub.URL, "failure,recovery", "", "", 0)
+	task.webhooks = []*webhookTarget{target}
+
+	// Failure, repeated failure, recovery, plain success
+	mockRunner.ShouldFail = true
+	runTask(task, triggerScheduled)
+	runTask(task, triggerScheduled)
+	mockRunner.ShouldFail = false
+	runTask(task, triggerScheduled)
+	runTask(task, triggerScheduled)
+
+	bodies := stub.received(t, 3)
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 notifications, got %d", len(bodies))
+	}
+
+	events := map[string]int{}
+	for _, body := range bodies {
+		var n notification
+		if err := json.Unmarshal([]byte(body), &n); err != nil {
+			t.Fatalf("invalid notification: %v", err)
+		}
+		if n.Task != "backup" || n.RunID == "" {
+			t.Errorf("unexpected notification: %+v", n)
+		}
+		events[n.Event]++
+	}
+	if events[eventFailure] != 2 || events[eventRecovery] != 1 {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
+
+// TestLoadWebhooks verifies webhook configuration from environment variables
+func TestLoadWebhooks(t *testing.T) {
+	tasks := []*CronSchedule{{name: "backup_db"}, {name: "report"}}
+	environ := []string{
+		"GRON_WEBHOOK_URL=http://global.invalid",
+		"GRON_WEBHOOK_FORMAT=slack",
+		"GRON_WEBHOOK_BACKUP_DB_URL=http://backup.invalid",
+		"GRON_WEBHOOK_BACKUP_DB_EVENTS=always",
+		"TASK_BACKUP_DB=@daily backup",
+	}
+
+	global, err := loadWebhooks(environ, tasks)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(global) != 1 || global[0].template == nil {
+		t.Errorf("expected one global slack webhook, got %+v", global)
+	}
+	if len(tasks[0].webhooks) != 1 || !tasks[0].webhooks[0].wants(eventSuccess) {
+		t.Errorf("expected backup_db to have a webhook for all events")
+	}
+	if len(tasks[1].webhooks) != 0 {
+		t.Errorf("expected report to have no webhooks")
+	}
+
+	invalid := [][]string{
+		{"GRON_WEBHOOK_MISSING_URL=http://x.invalid"},
+		{"GRON_WEBHOOK_EVENTS=failure"},
+		{"GRON_WEBHOOK_URL=http://x.invalid", "GRON_WEBHOOK_RETRIES=many"},
+		{"GRON_WEBHOOK_REPORT_COLOR=red"},
+	}
+	for _, env := range invalid {
+		if _, err := loadWebhooks(env, tasks); err == nil {
+			t.Errorf("expected error for %v", env)
+		}
+	}
+}
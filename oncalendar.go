@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// onCalendarShorthands are the shorthands of systemd calendar events, in
+// their normalized form.
+var onCalendarShorthands = map[string]string{
+	"minutely":     "*-*-* *:*:00",
+	"hourly":       "*-*-* *:00:00",
+	"daily":        "*-*-* 00:00:00",
+	"weekly":       "Mon *-*-* 00:00:00",
+	"monthly":      "*-*-01 00:00:00",
+	"quarterly":    "*-01,04,07,10-01 00:00:00",
+	"semiannually": "*-01,07-01 00:00:00",
+	"yearly":       "*-01-01 00:00:00",
+	"annually":     "*-01-01 00:00:00",
+}
+
+// Patterns of the parts of a systemd calendar event: the days of the week,
+// the date and the time. Components accept "*", numbers, ranges "a..b",
+// repetitions "a/n" and comma-separated lists of these.
+var (
+	onCalendarWeekdayPattern = regexp.MustCompile(`^[A-Za-z]+(\.\.[A-Za-z]+)?(,[A-Za-z]+(\.\.[A-Za-z]+)?)*$`)
+	onCalendarDatePattern    = regexp.MustCompile(`^([0-9*,./]+-)?[0-9*,./]+[-~][0-9*,./]+$`)
+	onCalendarTimePattern    = regexp.MustCompile(`^[0-9*,./]+:[0-9*,./]+(:[0-9*,./]+)?$`)
+)
+
+// onCalendarEvent holds the parts of a systemd calendar event as written.
+type onCalendarEvent struct {
+	weekdays, date, clock, zone string
+}
+
+// splitOnCalendar assigns the fields at the start of fields to the parts of
+// a systemd calendar event, in the order weekdays, date, time and time zone,
+// each of them optional. It returns the parts and the number of fields they
+// take, which is 0 if the first field is none of them.
+func splitOnCalendar(fields []string) (onCalendarEvent, int) {
+	var event onCalendarEvent
+	n := 0
+	if len(fields) > 0 {
+		if shorthand, ok := onCalendarShorthands[strings.ToLower(fields[0])]; ok {
+			event, _ = splitOnCalendar(strings.Fields(shorthand))
+			n = 1
+		}
+	}
+	if n == 0 {
+		if n < len(fields) && isWeekdayList(fields[n]) {
+			event.weekdays = fields[n]
+			n++
+		}
+		if n < len(fields) && onCalendarDatePattern.MatchString(fields[n]) {
+			event.date = fields[n]
+			n++
+		}
+		if n < len(fields) && onCalendarTimePattern.MatchString(fields[n]) {
+			event.clock = fields[n]
+			n++
+		}
+		if n == 0 {
+			return event, 0
+		}
+	}
+	if n < len(fields) && isOnCalendarZone(fields[n]) {
+		event.zone = fields[n]
+		n++
+	}
+	return event, n
+}
+
+// isOnCalendarZone reports whether field is the time zone of a calendar
+// event: "UTC", "Local" or an IANA zone such as "Europe/Berlin". Other names
+// the zone database knows, such as "Japan", are taken for commands.
+func isOnCalendarZone(field string) bool {
+	if field == "UTC" || field == "Local" {
+		return true
+	}
+	if !strings.Contains(field, "/") {
+		return false
+	}
+	_, err := time.LoadLocation(field)
+	return err == nil
+}
+
+// isWeekdayList reports whether field is a list of day of the week names.
+func isWeekdayList(field string) bool {
+	if !onCalendarWeekdayPattern.MatchString(field) {
+		return false
+	}
+	for _, name := range strings.FieldsFunc(field, func(r rune) bool { return r == ',' || r == '.' }) {
+		if shortDayName(name) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// shortDayName returns the three-letter name of a day of the week given by
+// its short or full English name, or an empty string if there is none.
+func shortDayName(name string) string {
+	for i, day := range dayNames {
+		if strings.EqualFold(name, day) || strings.EqualFold(name, time.Weekday(i).String()) {
+			return day
+		}
+	}
+	return ""
+}
+
+// onCalendarFieldCount returns the number of fields taken by an @oncalendar
+// schedule at the start of fields, including "@oncalendar" itself. A first
+// field that is no part of a calendar event is counted, so that parsing the
+// schedule reports it. A time zone is only counted if the command follows it,
+// so that a command named like a zone is not taken for one.
+func onCalendarFieldCount(fields []string) int {
+	event, n := splitOnCalendar(fields[1:])
+	if event.zone != "" && n == len(fields)-1 {
+		n--
+	}
+	return 1 + max(n, 1)
+}
+
+// parseOnCalendar parses a systemd calendar event, as used by OnCalendar= of
+// timer units: "Mon..Fri *-*-* 09:00:00", "*-*-01 00:00:00", "*-02~1 12:00"
+// or a shorthand such as "weekly", optionally followed by a time zone. gron
+// runs tasks at minute precision, so seconds must be 0.
+func parseOnCalendar(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty calendar event")
+	}
+	event, n := splitOnCalendar(fields)
+	if n < len(fields) {
+		return nil, fmt.Errorf("invalid calendar event '%s': unexpected '%s'", expr, fields[n])
+	}
+
+	schedule := &CronSchedule{isOnCalendar: true, zoneName: event.zone}
+	var err error
+	if schedule.daysOfWeek, err = parseOnCalendarWeekdays(event.weekdays); err != nil {
+		return nil, err
+	}
+	if err = schedule.parseOnCalendarDate(event.date); err != nil {
+		return nil, err
+	}
+	if err = schedule.parseOnCalendarTime(event.clock); err != nil {
+		return nil, err
+	}
+	if event.zone != "" {
+		schedule.location, _ = time.LoadLocation(event.zone)
+	}
+	return schedule, nil
+}
+
+// parseOnCalendarWeekdays parses the days of the week of a calendar event,
+// e.g. "Mon..Fri" or "Sat,Sunday". No days stand for every day.
+func parseOnCalendarWeekdays(field string) ([]int, error) {
+	if field == "" {
+		return parseField("*", cronFields[4])
+	}
+	var parts []string
+	for _, part := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(part, "..")
+		part = shortDayName(from)
+		if isRange {
+			part += "-" + shortDayName(to)
+		}
+		parts = append(parts, part)
+	}
+	days, err := parseWindowDays(strings.Join(parts, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid weekdays '%s'", field)
+	}
+	return days, nil
+}
+
+// parseOnCalendarDate parses the date of a calendar event: "[year-]month-day",
+// or "[year-]month~day" with the day counted back from the end of the month,
+// 1 being the last day. No date stands for every day.
+func (s *CronSchedule) parseOnCalendarDate(field string) error {
+	if field == "" {
+		field = "*-*-*"
+	}
+	rest, lastDays, fromEnd := strings.Cut(field, "~")
+	components := strings.Split(rest, "-")
+	if fromEnd {
+		components = append(components, lastDays)
+	}
+	if len(components) == 2 {
+		components = append([]string{"*"}, components...)
+	}
+	if len(components) != 3 {
+		return fmt.Errorf("invalid date '%s', expected [year-]month-day", field)
+	}
+
+	var err error
+	if components[0] != "*" {
+		if s.years, err = parseOnCalendarComponent(components[0], yearField, "year"); err != nil {
+			return err
+		}
+	}
+	if s.months, err = parseOnCalendarComponent(components[1], cronFields[3], "month"); err != nil {
+		return err
+	}
+	if s.daysOfMonth, err = parseOnCalendarComponent(components[2], cronFields[2], "day"); err != nil {
+		return err
+	}
+	if fromEnd {
+		s.lastDays = lastDaysOf(components[2])
+		s.daysOfMonth, _ = parseField("*", cronFields[2])
+	}
+	return nil
+}
+
+// lastDaysOf returns the days counted back from the end of the month that a
+// day component after "~" stands for. Repetitions count backwards too:
+// "7/1" is the last seven days.
+func lastDaysOf(component string) []int {
+	var result []int
+	for _, part := range strings.Split(component, ",") {
+		first, step, hasStep := strings.Cut(part, "/")
+		if !hasStep || strings.Contains(first, "..") {
+			values, _ := parseOnCalendarComponent(part, cronFields[2], "day")
+			result = append(result, values...)
+			continue
+		}
+		start, _ := parseField(first, cronFields[2])
+		n, _ := parseField(step, CronField{1, 31})
+		for d := start[0]; d >= 1; d -= n[0] {
+			result = append(result, d)
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// parseOnCalendarTime parses the time of a calendar event, "hour:minute" or
+// "hour:minute:second". No time stands for midnight.
+func (s *CronSchedule) parseOnCalendarTime(field string) error {
+	if field == "" {
+		field = "00:00:00"
+	}
+	components := strings.Split(field, ":")
+	var err error
+	if s.hours, err = parseOnCalendarComponent(components[0], cronFields[1], "hour"); err != nil {
+		return err
+	}
+	if s.minutes, err = parseOnCalendarComponent(components[1], cronFields[0], "minute"); err != nil {
+		return err
+	}
+	if len(components) == 3 {
+		seconds, err := parseOnCalendarComponent(components[2], cronFields[0], "second")
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(seconds, []int{0}) {
+			return fmt.Errorf("invalid second '%s': gron runs tasks at minute precision, use 00", components[2])
+		}
+	}
+	return nil
+}
+
+// parseOnCalendarComponent parses a component of the date or time of a
+// calendar event, written with ".." for ranges instead of "-".
+func parseOnCalendarComponent(component string, limits CronField, name string) ([]int, error) {
+	if strings.Contains(component, "-") {
+		return nil, fmt.Errorf("invalid %s '%s'", name, component)
+	}
+	values, err := parseField(strings.ReplaceAll(component, "..", "-"), limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s': %v", name, component, err)
+	}
+	return values, nil
+}
+
+// matchesDayOfMonth reports whether the day of t is one of the days of the
+// month of the schedule.
+func (s *CronSchedule) matchesDayOfMonth(t time.Time) bool {
+	if s.lastDays != nil {
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return slices.Contains(s.lastDays, last-t.Day()+1)
+	}
+	return slices.Contains(s.daysOfMonth, t.Day())
+}
+
+// normalizedOnCalendar writes a calendar event in the normalized form of
+// systemd-analyze calendar, e.g. "Mon..Fri *-*-* 09:00:00".
+func (s *CronSchedule) normalizedOnCalendar() string {
+	var parts []string
+	if days := s.normalizedDaysOfWeek(); len(days) < 7 {
+		// Weeks start on Monday.
+		for i, d := range days {
+			days[i] = (d + 6) % 7
+		}
+		slices.Sort(days)
+		parts = append(parts, formatOnCalendarComponent(days, cronFields[4], func(d int) string {
+			return time.Weekday((d + 1) % 7).String()[:3]
+		}))
+	}
+
+	year := "*"
+	if s.years != nil {
+		year = formatOnCalendarComponent(s.years, yearField, func(y int) string { return fmt.Sprintf("%04d", y) })
+	}
+	date := year + "-" + formatOnCalendarComponent(s.months, cronFields[3], twoDigits)
+	if s.lastDays != nil {
+		date += "~" + formatOnCalendarComponent(s.lastDays, cronFields[2], twoDigits)
+	} else {
+		date += "-" + formatOnCalendarComponent(s.daysOfMonth, cronFields[2], twoDigits)
+	}
+	parts = append(parts, date,
+		formatOnCalendarComponent(s.hours, cronFields[1], twoDigits)+":"+
+			formatOnCalendarComponent(s.minutes, cronFields[0], twoDigits)+":00")
+	if s.zoneName != "" {
+		parts = append(parts, s.zoneName)
+	}
+	return strings.Join(parts, " ")
+}
+
+// twoDigits writes a value of a date or time component with two digits.
+func twoDigits(v int) string {
+	return fmt.Sprintf("%02d", v)
+}
+
+// formatOnCalendarComponent writes the sorted values of a component of a
+// calendar event: "*" for all of them, "a/n" for a repetition of more than
+// four values up to the end of the range, and otherwise a comma-separated list of values and ranges
+// "a..b" of three or more values.
+func formatOnCalendarComponent(values []int, limits CronField, name func(int) string) string {
+	if len(values) == limits.max-limits.min+1 {
+		return "*"
+	}
+	if len(values) > 4 {
+		step := values[1] - values[0]
+		regular := step > 1 && values[len(values)-1]+step > limits.max
+		for i := 2; i < len(values) && regular; i++ {
+			regular = values[i]-values[i-1] == step
+		}
+		if regular {
+			return fmt.Sprintf("%s/%d", name(values[0]), step)
+		}
+	}
+
+	var items []string
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			items = append(items, name(values[i])+".."+name(values[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				items = append(items, name(values[k]))
+			}
+		}
+		i = j + 1
+	}
+	return strings.Join(items, ",")
+}
+
+// describeLastDays describes days of the month counted back from its end.
+func describeLastDays(days []int) string {
+	switch {
+	case len(days) == 1 && days[0] == 1:
+		return "on the last day of the month"
+	case days[0] == 1 && days[len(days)-1] == len(days):
+		return fmt.Sprintf("on the last %d days of the month", len(days))
+	}
+	return "on days " + joinAnd(describeValues(days, strconv.Itoa)) + " counted back from the end of the month"
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseOnCalendar verifies systemd calendar events and their normalized form
+func TestParseOnCalendar(t *testing.T) {
+	tests := []struct {
+		expr       string
+		normalized string
+	}{
+		{"Mon..Fri *-*-* 09:00:00", "Mon..Fri *-*-* 09:00:00"},
+		{"*-*-01 00:00:00", "*-*-01 00:00:00"},
+		{"weekly", "Mon *-*-* 00:00:00"},
+		{"quarterly", "*-01,04,07,10-01 00:00:00"},
+		{"Sat,Sunday 10:30", "Sat,Sun *-*-* 10:30:00"},
+		{"mon,tue,wed 8:00", "Mon..Wed *-*-* 08:00:00"},
+		{"*:0/10", "*-*-* *:00/10:00"},
+		{"Mon 2026-11-1..7 18:00", "Mon 2026-11-01..07 18:00:00"},
+		{"12-24", "*-12-24 00:00:00"},
+		{"*-02~1 12:00", "*-02~01 12:00:00"},
+		{"Fri *-*~7/1", "Fri *-*~01..07 00:00:00"},
+		{"daily UTC", "*-*-* 00:00:00 UTC"},
+		{"daily Local", "*-*-* 00:00:00 Local"},
+		{"12:00 Asia/Tokyo", "*-*-* 12:00:00 Asia/Tokyo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseSchedule("@oncalendar " + tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := s.normalized(); got != "@oncalendar "+tt.normalized {
+				t.Errorf("expected '@oncalendar %s', got '%s'", tt.normalized, got)
+			}
+			// The normalized form parses to the same schedule.
+			again, err := parseSchedule(s.normalized())
+			if err != nil || again.normalized() != s.normalized() {
+				t.Errorf("normalized form does not parse back: %v", err)
+			}
+		})
+	}
+}
+
+// TestParseOnCalendarErrors verifies invalid calendar events are rejected
+func TestParseOnCalendarErrors(t *testing.T) {
+	tests := []struct {
+		expr        string
+		expectedErr string
+	}{
+		{"@oncalendar", "empty calendar event"},
+		{"@oncalendar Mon..Fro 09:00", "unexpected 'Mon..Fro'"},
+		{"@oncalendar daily 09:00", "unexpected '09:00'"},
+		{"@oncalendar Fri..Mon", "invalid weekdays 'Fri..Mon'"},
+		{"@oncalendar *-13-01", "invalid month '13'"},
+		{"@oncalendar *-*-32", "invalid day '32'"},
+		{"@oncalendar 25:00", "invalid hour '25'"},
+		{"@oncalendar 09:00:30", "minute precision"},
+		{"@oncalendar daily Japan", "unexpected 'Japan'"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseSchedule(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Errorf("%q: expected error containing '%s', got %v", tt.expr, tt.expectedErr, err)
+		}
+	}
+}
+
+// TestOnCalendarSchedule verifies the fire times and descriptions of calendar events
+func TestOnCalendarSchedule(t *testing.T) {
+	tests := []struct {
+		expr        string
+		from        string
+		expected    []string
+		description string
+	}{
+		{
+			expr:        "Mon..Fri *-*-* 09:00:00",
+			from:        "2026-10-23T10:00:00Z",
+			expected:    []string{"2026-10-26T09:00:00Z", "2026-10-27T09:00:00Z"},
+			description: "at 09:00, Monday through Friday",
+		},
+		{
+			expr:        "*-*~1 23:00",
+			from:        "2026-02-01T00:00:00Z",
+			expected:    []string{"2026-02-28T23:00:00Z", "2026-03-31T23:00:00Z"},
+			description: "at 23:00, on the last day of the month",
+		},
+		{
+			expr:        "Sun *-*~7/1 04:00",
+			from:        "2026-10-01T00:00:00Z",
+			expected:    []string{"2026-10-25T04:00:00Z", "2026-11-29T04:00:00Z"},
+			description: "at 04:00, on the last 7 days of the month, on Sunday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseSchedule("@oncalendar " + tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s.location = time.UTC
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			times := s.upcoming(from, len(tt.expected))
+			if len(times) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, times)
+			}
+			for i, want := range tt.expected {
+				if got := times[i].Format(time.RFC3339); got != want {
+					t.Errorf("run %d: expected %s, got %s", i, want, got)
+				}
+			}
+			if !s.shouldRun(times[0]) {
+				t.Errorf("expected a run at %s", tt.expected[0])
+			}
+			if got := s.describe(); got != tt.description {
+				t.Errorf("expected description '%s', got '%s'", tt.description, got)
+			}
+		})
+	}
+}
+
+// TestOnCalendarTaskZones verifies which word after a calendar event is its time zone
+func TestOnCalendarTaskZones(t *testing.T) {
+	tests := []struct {
+		definition string
+		spec       string
+		command    string
+	}{
+		{"@oncalendar daily Europe/Berlin backup.sh", "@oncalendar daily Europe/Berlin", "backup.sh"},
+		{"@oncalendar daily Local backup.sh", "@oncalendar daily Local", "backup.sh"},
+		{"@oncalendar daily UTC backup.sh", "@oncalendar daily UTC", "backup.sh"},
+		{"@oncalendar daily Japan backup.sh", "@oncalendar daily", "Japan backup.sh"},
+		{"@oncalendar daily UTC", "@oncalendar daily", "UTC"},
+		{"@oncalendar 09:00 Europe/Berlin", "@oncalendar 09:00", "Europe/Berlin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			spec, command, err := splitTaskDefinition(tt.definition)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if spec != tt.spec || command != tt.command {
+				t.Errorf("expected '%s' and '%s', got '%s' and '%s'", tt.spec, tt.command, spec, command)
+			}
+		})
+	}
+}
+
+// TestOnCalendarTasks verifies calendar events in task definitions and their time zones
+func TestOnCalendarTasks(t *testing.T) {
+	environ := []string{
+		"TASK_REPORT=@oncalendar Mon..Fri *-*-* 09:00:00 /scripts/report.sh --daily",
+		"TASK_CLEANUP=@oncalendar weekly UTC cleanup.sh",
+		"TASK_CLEANUP_TIMEZONE=Local",
+	}
+	tasks, errs := parseTasks(environ)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		switch task.name {
+		case "report":
+			if task.spec != "@oncalendar Mon..Fri *-*-* 09:00:00" || task.command != "/scripts/report.sh --daily" {
+				t.Errorf("unexpected schedule '%s' and command '%s'", task.spec, task.command)
+			}
+		case "cleanup":
+			if task.command != "cleanup.sh" {
+				t.Errorf("unexpected command '%s'", task.command)
+			}
+			// The time zone of the expression takes precedence.
+			if task.location != time.UTC {
+				t.Errorf("expected the UTC time zone, got %v", task.location)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPingTimeout bounds each ping so a slow monitor cannot pile up requests.
+const defaultPingTimeout = 10 * time.Second
+
+// pingTarget is a healthchecks.io-style monitor URL pinged when a task starts
+// ("<url>/start"), succeeds ("<url>") and fails ("<url>/fail").
+type pingTarget struct {
+	url      string
+	exitCode bool // Ping "<url>/<exit code>" instead of "<url>" and "<url>/fail".
+	output   bool // Send the output tail as the body of the final ping.
+	client   *http.Client
+}
+
+// newPingTarget creates a ping target.
+func newPingTarget(url string, exitCode, output bool, timeout time.Duration) (*pingTarget, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("invalid ping URL '%s'", url)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("ping timeout must be positive, got %v", timeout)
+	}
+	return &pingTarget{
+		url:      strings.TrimSuffix(url, "/"),
+		exitCode: exitCode,
+		output:   output,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// track pings the start of a run in the background and returns a channel on
+// which the finished run must be sent to ping its outcome. The pings are sent
+// in order without ever blocking the run.
+func (p *pingTarget) track(task string) chan<- runRecord {
+	done := make(chan runRecord, 1)
+	go func() {
+		p.send(task, "/start", nil)
+		record := <-done
+
+		suffix := ""
+		if p.exitCode && record.ExitCode >= 0 {
+			suffix = "/" + strconv.Itoa(record.ExitCode)
+		} else if record.ExitCode != 0 {
+			// Commands that could not be started have no exit code to report.
+			suffix = "/fail"
+		}
+		var body []byte
+		if p.output {
+			body = []byte(record.Output)
+		}
+		p.send(task, suffix, body)
+	}()
+	return done
+}
+
+// send performs a single ping, logging failures.
+func (p *pingTarget) send(task, suffix string, body []byte) {
+	resp, err := p.client.Post(p.url+suffix, "text/plain; charset=utf-8", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to ping monitor for task '%s': %v", task, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Failed to ping monitor for task '%s': unexpected response %s", task, resp.Status)
+	}
+}
+
+// pingOptions are the ping settings read from the environment.
+var pingOptions = []string{"URL", "EXIT_CODE", "OUTPUT", "TIMEOUT"}
+
+// loadPings configures monitor pings from environment variables:
+// GRON_PING_<TASK>_URL, GRON_PING_<TASK>_EXIT_CODE, GRON_PING_<TASK>_OUTPUT and
+// GRON_PING_<TASK>_TIMEOUT for a single task. The global GRON_PING_<OPTION>
+// variables set defaults; a global URL must contain "{task}", which is replaced
+// by the task name, and then applies to every task.
+func loadPings(environ []string, tasks []*CronSchedule) error {
+	settings, err := scopedSettings(environ, "GRON_PING_", pingOptions)
+	if err != nil {
+		return err
+	}
+	defaults := settings[""]
+	if url := defaults["URL"]; url != "" && !strings.Contains(url, "{task}") {
+		return fmt.Errorf("GRON_PING_URL: must contain {task}")
+	}
+
+	byName := make(map[string]*CronSchedule)
+	for _, task := range tasks {
+		byName[task.name] = task
+	}
+	for _, name := range sortedNames(settings) {
+		if _, ok := byName[name]; name != "" && !ok {
+			return fmt.Errorf("GRON_PING_%s_URL: unknown task '%s'", strings.ToUpper(name), name)
+		}
+	}
+
+	for _, task := range tasks {
+		opts := make(map[string]string)
+		for k, v := range defaults {
+			opts[k] = v
+		}
+		for k, v := range settings[task.name] {
+			opts[k] = v
+		}
+		if opts["URL"] == "" {
+			continue
+		}
+
+		prefix := "GRON_PING_" + strings.ToUpper(task.name) + "_"
+		timeout := defaultPingTimeout
+		if v := opts["TIMEOUT"]; v != "" {
+			if timeout, err = time.ParseDuration(v); err != nil {
+				return fmt.Errorf("%sTIMEOUT: %v", prefix, err)
+			}
+		}
+		exitCode, err := parseBoolOption(opts["EXIT_CODE"])
+		if err != nil {
+			return fmt.Errorf("%sEXIT_CODE: %v", prefix, err)
+		}
+		output, err := parseBoolOption(opts["OUTPUT"])
+		if err != nil {
+			return fmt.Errorf("%sOUTPUT: %v", prefix, err)
+		}
+
+		url := strings.ReplaceAll(opts["URL"], "{task}", task.name)
+		if task.ping, err = newPingTarget(url, exitCode, output, timeout); err != nil {
+			return fmt.Errorf("%sURL: %v", prefix, err)
+		}
+	}
+	return nil
+}
+
+// parseBoolOption parses an optional boolean setting, defaulting to false.
+func parseBoolOption(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
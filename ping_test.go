@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pingStub is a local monitor recording the paths and bodies of pings
+type pingStub struct {
+	*httptest.Server
+	mu    sync.Mutex
+	pings []string
+	delay time.Duration
+}
+
+// newPingStub starts a monitor stub that responds after delay
+func newPingStub(t *testing.T, delay time.Duration) *pingStub {
+	stub := &pingStub{delay: delay}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.mu.Lock()
+		stub.pings = append(stub.pings, r.URL.Path+" "+string(body))
+		stub.mu.Unlock()
+		time.Sleep(stub.delay)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// wait returns the pings once count of them arrived or the deadline passed
+func (s *pingStub) wait(count int) []string {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		n := len(s.pings)
+		s.mu.Unlock()
+		if n >= count {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.pings...)
+}
+
+// TestPingLifecycle verifies start, success and failure pings are sent in order
+func TestPingLifecycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode bool
+		output   bool
+		record   runRecord
+		expected []string
+	}{
+		{"success", false, false, runRecord{Output: "ok"}, []string{"/check/start ", "/check "}},
+		{"failure", false, false, runRecord{ExitCode: 2}, []string{"/check/start ", "/check/fail "}},
+		{"exit_code", true, false, runRecord{ExitCode: 2}, []string{"/check/start ", "/check/2 "}},
+		{"not_started", true, false, runRecord{ExitCode: -1}, []string{"/check/start ", "/check/fail "}},
+		{"output", false, true, runRecord{Output: "done"}, []string{"/check/start ", "/check done"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newPingStub(t, 0)
+			target, err := newPingTarget(stub.URL+"/check/", tt.exitCode, tt.output, time.Second)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			target.track("backup") <- tt.record
+
+			pings := stub.wait(2)
+			if len(pings) != 2 || pings[0] != tt.expected[0] || pings[1] != tt.expected[1] {
+				t.Errorf("expected pings %q, got %q", tt.expected, pings)
+			}
+		})
+	}
+}
+
+// TestPingDoesNotDelayRun verifies a slow monitor never delays the task
+func TestPingDoesNotDelayRun(t *testing.T) {
+	mockRunner := &MockCommandRunner{}
+	originalRunner := defaultCommandRunner
+	setCommandRunner(mockRunner)
+	defer setCommandRunner(originalRunner)
+	useTestHistory(t)
+
+	stub := newPingStub(t, 500*time.Millisecond)
+	task := newTestRegistry().get("backup")
+	task.ping, _ = newPingTarget(stub.URL, false, false, 100*time.Millisecond)
+
+	start := time.Now()
+	runTask(task, triggerScheduled)
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("expected run not to wait for the monitor, took %v", elapsed)
+	}
+	if len(mockRunner.Commands) != 1 {
+		t.Errorf("expected the command to run once, got %d", len(mockRunner.Commands))
+	}
+	if pings := stub.wait(2); len(pings) != 2 {
+		t.Errorf("expected 2 pings despite timeouts, got %q", pings)
+	}
+}
+
+// TestLoadPings verifies ping configuration from environment variables
+func TestLoadPings(t *testing.T) {
+	tasks := []*CronSchedule{{name: "backup"}, {name: "report"}}
+	environ := []string{
+		"GRON_PING_URL=https://hc-ping.com/key/{task}",
+		"GRON_PING_TIMEOUT=5s",
+		"GRON_PING_BACKUP_URL=https://hc-ping.com/uuid",
+		"GRON_PING_BACKUP_EXIT_CODE=true",
+	}
+
+	if err := loadPings(environ, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tasks[0].ping.url != "https://hc-ping.com/uuid" || !tasks[0].ping.exitCode {
+		t.Errorf("unexpected backup ping: %+v", tasks[0].ping)
+	}
+	if tasks[1].ping.url != "https://hc-ping.com/key/report" || tasks[1].ping.client.Timeout != 5*time.Second {
+		t.Errorf("unexpected report ping: %+v", tasks[1].ping)
+	}
+
+	invalid := [][]string{
+		{"GRON_PING_URL=https://hc-ping.com/fixed"},
+		{"GRON_PING_MISSING_URL=https://hc-ping.com/uuid"},
+		{"GRON_PING_BACKUP_URL=hc-ping.com/uuid"},
+		{"GRON_PING_BACKUP_URL=https://hc-ping.com/uuid", "GRON_PING_BACKUP_OUTPUT=maybe"},
+		{"GRON_PING_BACKUP_URL=https://hc-ping.com/uuid", "GRON_PING_BACKUP_TIMEOUT=0s"},
+	}
+	for _, env := range invalid {
+		if err := loadPings(env, []*CronSchedule{{name: "backup"}}); err == nil {
+			t.Errorf("expected error for %v", env)
+		}
+	}
+}
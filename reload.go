@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloader reloads the tasks from the sources gron was started with.
+type reloader struct {
+	mu       sync.Mutex
+	path     string   // Configuration file, if any.
+	crontabs []string // Crontab files and cron.d directories.
+	environ  []string
+	registry *taskRegistry
+}
+
+// reload loads the configuration again and applies the changes to the
+// registry. If anything fails to load, the whole reload is rejected and the
+// running tasks are left untouched.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, err := loadConfiguration(r.path, r.crontabs, r.environ)
+	if err != nil {
+		return err
+	}
+	if len(config.errors) > 0 {
+		return errors.Join(config.errors...)
+	}
+
+	added, changed, removed := applyTasks(r.registry, config.tasks)
+	setNotifiers(config.webhooks, config.mailer)
+	health.setLoaded(len(config.tasks), nil)
+	log.Printf("Reloaded %d tasks: %d added, %d changed, %d removed", len(config.tasks), added, changed, removed)
+	return nil
+}
+
+// applyTasks replaces the tasks of the registry. Tasks whose definition did
+// not change are kept with their state; the @every tickers of new and changed
+// tasks are started, those of changed and removed tasks stopped, and new and
+// changed tasks that run on start are run. @reboot tasks only run when gron
+// starts. Runs in progress are never interrupted.
+func applyTasks(reg *taskRegistry, tasks []*CronSchedule) (added, changed, removed int) {
+	current := make(map[string]*CronSchedule)
+	for _, task := range reg.list() {
+		current[task.name] = task
+	}
+
+	var next, started []*CronSchedule
+	for _, task := range tasks {
+		old, ok := current[task.name]
+		delete(current, task.name)
+		switch {
+		case !ok:
+			log.Printf("Adding task '%s'", task.name)
+			added++
+		case old.fingerprint() == task.fingerprint():
+			next = append(next, old)
+			continue
+		default:
+			log.Printf("Updating task '%s'", task.name)
+			old.state.stopTicker()
+			changed++
+		}
+		next = append(next, task)
+		started = append(started, task)
+	}
+	for name, task := range current {
+		log.Printf("Removing task '%s'", name)
+		task.state.stopTicker()
+		removed++
+	}
+
+	reg.set(next)
+	createEveryTickers(started)
+	runStartTasks(started, false)
+	return added, changed, removed
+}
+
+// fingerprint describes everything that defines a task, so that two tasks
+// with the same fingerprint behave identically.
+func (s *CronSchedule) fingerprint() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n%s\n%+v\n%d %v %v\n", s.spec, s.normalized(), s.command, s.exec, s.retries, s.retryDelay, s.location)
+	fmt.Fprintf(&b, "%v %v %v %v %v\n", s.startDelay, s.jitter, s.initialDelay, s.runOnStart, s.runMissed)
+	fmt.Fprintf(&b, "%v %v\n", s.notBefore, s.notAfter)
+	if s.window != nil {
+		fmt.Fprintf(&b, "window %s\n", s.window.spec)
+	}
+	for _, cal := range s.skipOn {
+		fmt.Fprintf(&b, "skip %s\n", cal.fingerprint())
+	}
+	for _, cal := range s.onlyOn {
+		fmt.Fprintf(&b, "only %s\n", cal.fingerprint())
+	}
+	for _, w := range s.webhooks {
+		events := make([]string, 0, len(w.events))
+		for event := range w.events {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+		template := ""
+		if w.template != nil {
+			template = w.template.Tree.Root.String()
+		}
+		fmt.Fprintf(&b, "webhook %s %v %d %q\n", w.url, events, w.retries, template)
+	}
+	if s.mail != nil {
+		fmt.Fprintf(&b, "mail %v %s\n", s.mail.to, s.mail.on)
+	}
+	if s.ping != nil {
+		fmt.Fprintf(&b, "ping %s %v %v %v\n", s.ping.url, s.ping.exitCode, s.ping.output, s.ping.client.Timeout)
+	}
+	return b.String()
+}
+
+// watch polls the configuration file, crontabs and calendars every interval
+// and reloads when any of them changed. It never returns.
+func (r *reloader) watch(interval time.Duration) {
+	last := r.snapshot()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current := r.snapshot()
+		if current == last {
+			continue
+		}
+		log.Printf("Configuration changed, reloading")
+		if err := r.reload(); err != nil {
+			log.Printf("Rejected reload: %v", err)
+		}
+		// The reload may have changed which calendars are watched.
+		last = r.snapshot()
+	}
+}
+
+// snapshot describes the names, sizes and modification times of the watched
+// files, including the files of watched directories and the calendars used by
+// the registered tasks.
+func (r *reloader) snapshot() string {
+	var b strings.Builder
+	paths := r.crontabs
+	if r.path != "" {
+		paths = append([]string{r.path}, paths...)
+	}
+	paths = append(paths, r.calendarPaths()...)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&b, "%s missing\n", path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %d %v\n", path, info.Size(), info.ModTime().UnixNano())
+		if !info.IsDir() {
+			continue
+		}
+		entries, _ := os.ReadDir(path)
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				fmt.Fprintf(&b, "%s %d %v\n", filepath.Join(path, entry.Name()), info.Size(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	return b.String()
+}
+
+// calendarPaths returns the sorted files of the calendars used by the
+// registered tasks.
+func (r *reloader) calendarPaths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, task := range r.registry.list() {
+		for _, cal := range append(append([]*calendar(nil), task.skipOn...), task.onlyOn...) {
+			if !seen[cal.path] {
+				seen[cal.path] = true
+				paths = append(paths, cal.path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
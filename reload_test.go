@@ -0,0 +1,195 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mustParseTask parses a task definition for tests
+func mustParseTask(t *testing.T, name, spec, command string) *CronSchedule {
+	t.Helper()
+	task, err := parseSchedule(spec)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", spec, err)
+	}
+	task.name = name
+	task.command = command
+	return task
+}
+
+// stopTickers stops the @every tickers of the registered tasks
+func stopTickers(reg *taskRegistry) {
+	for _, task := range reg.list() {
+		task.state.stopTicker()
+	}
+}
+
+// TestApplyTasks verifies unchanged tasks keep their state and others are replaced
+func TestApplyTasks(t *testing.T) {
+	reg := &taskRegistry{}
+	backup := mustParseTask(t, "backup", "0 2 * * *", "backup.sh")
+	poll := mustParseTask(t, "poll", "@every 1h", "poll.sh")
+	report := mustParseTask(t, "report", "@daily", "report.sh")
+	reg.set([]*CronSchedule{backup, poll, report})
+	createEveryTickers(reg.list())
+	defer stopTickers(reg)
+	backup.state.setPaused(true)
+
+	added, changed, removed := applyTasks(reg, []*CronSchedule{
+		mustParseTask(t, "backup", "0 2 * * *", "backup.sh"),
+		mustParseTask(t, "poll", "@every 30m", "poll.sh"),
+		mustParseTask(t, "cleanup", "@hourly", "cleanup.sh"),
+	})
+	if added != 1 || changed != 1 || removed != 1 {
+		t.Errorf("expected 1 added, changed and removed, got %d, %d, %d", added, changed, removed)
+	}
+
+	if reg.get("backup") != backup || !reg.get("backup").state.isPaused() {
+		t.Error("expected the unchanged task to be kept with its state")
+	}
+	if reg.get("poll") == poll || reg.get("poll").interval != 30*time.Minute {
+		t.Error("expected the changed task to be replaced")
+	}
+	if reg.get("report") != nil || reg.get("cleanup") == nil {
+		t.Error("expected report to be removed and cleanup added")
+	}
+
+	poll.state.mu.Lock()
+	stopped := poll.state.stop == nil
+	poll.state.mu.Unlock()
+	if !stopped {
+		t.Error("expected the ticker of the replaced task to be stopped")
+	}
+	if reg.get("poll").next(time.Now()).IsZero() {
+		t.Error("expected the ticker of the new task to be started")
+	}
+}
+
+// TestReload verifies reloads from the configuration file and rejection of invalid files
+func TestReload(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", "tasks:\n  backup:\n    schedule: '@daily'\n    command: backup.sh\n")
+	reg := &taskRegistry{}
+	defer stopTickers(reg)
+	r := &reloader{path: path, registry: reg}
+
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.get("backup") == nil {
+		t.Fatal("expected backup to be loaded")
+	}
+	before := r.snapshot()
+
+	invalid := "tasks:\n  backup:\n    schedule: '@daily'\n    command: backup.sh\n  bad:\n    schedule: '61 * * * *'\n    command: echo\n"
+	if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if r.snapshot() == before {
+		t.Error("expected the snapshot to change with the file")
+	}
+	if err := r.reload(); err == nil {
+		t.Error("expected the reload to be rejected")
+	}
+	if len(reg.list()) != 1 {
+		t.Errorf("expected the tasks to be left untouched, got %d", len(reg.list()))
+	}
+
+	if err := os.WriteFile(path, []byte("tasks:\n  poll:\n    schedule: '@every 1m'\n    command: poll.sh\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.get("backup") != nil || reg.get("poll") == nil {
+		t.Error("expected backup to be replaced by poll")
+	}
+}
+
+// TestSnapshotWatchesCalendars verifies the calendars of loaded tasks are part of the snapshot
+func TestSnapshotWatchesCalendars(t *testing.T) {
+	holidays := writeConfig(t, "holidays", "2026-12-25\n")
+	path := writeConfig(t, "gron.yaml", "calendars:\n  holidays: "+holidays+"\n"+
+		"tasks:\n  backup:\n    schedule: '@daily'\n    command: backup.sh\n    skip_on: holidays\n")
+	reg := &taskRegistry{}
+	defer stopTickers(reg)
+	r := &reloader{path: path, registry: reg}
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := r.snapshot()
+
+	if err := os.WriteFile(holidays, []byte("2026-12-25\n2026-12-26\n"), 0o600); err != nil {
+		t.Fatalf("failed to write calendar: %v", err)
+	}
+	if r.snapshot() == before {
+		t.Error("expected the snapshot to change with the calendar")
+	}
+}
+
+// TestReloadCrontabKeepsNames verifies crontab tasks keep their name and state when lines are inserted above them
+func TestReloadCrontabKeepsNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crontab")
+	if err := os.WriteFile(path, []byte("0 2 * * * backup.sh\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reg := &taskRegistry{}
+	defer stopTickers(reg)
+	r := &reloader{crontabs: []string{path}, registry: reg}
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks := reg.list()
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	backup := tasks[0]
+	backup.state.setPaused(true)
+
+	content := "# Nightly jobs\n*/5 * * * * poll.sh\n\n0 2 * * * backup.sh\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reg.list()) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(reg.list()))
+	}
+	if reg.get(backup.name) != backup || !backup.state.isPaused() {
+		t.Errorf("expected task %s to be kept with its state", backup.name)
+	}
+}
+
+// TestStartCronSchedulerSignalsStarted verifies reloads can only begin once the initial tickers run
+func TestStartCronSchedulerSignalsStarted(t *testing.T) {
+	reg := &taskRegistry{}
+	poll := mustParseTask(t, "poll", "@every 1h", "poll.sh")
+	reg.set([]*CronSchedule{poll})
+	defer stopTickers(reg)
+
+	started := make(chan struct{})
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		startCronScheduler(reg, started, stop)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the scheduler to signal the initial scheduling")
+	}
+
+	poll.state.mu.Lock()
+	running := poll.state.stop != nil
+	poll.state.mu.Unlock()
+	if !running {
+		t.Error("expected the ticker to be started before the signal")
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math/rand/v2"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trigger types recorded for each run.
+const (
+	triggerScheduled = "scheduled"
+	triggerManual    = "manual"
+	triggerRetry     = "retry"
+	triggerCatchUp   = "catch-up"
+	triggerStartup   = "startup"
+)
+
+// maxOutputTail is the number of trailing output bytes kept for each run.
+const maxOutputTail = 4096
+
+// runRecord describes a single execution of a task.
+type runRecord struct {
+	ID       string    `json:"id"`
+	Task     string    `json:"task"`
+	Command  string    `json:"command"`
+	Trigger  string    `json:"trigger"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+	Output   string    `json:"output,omitempty"`
+}
+
+// Duration returns how long the run took.
+func (r runRecord) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// taskState holds the runtime state of a task shared between the scheduler
+// and the admin API.
+type taskState struct {
+	mu         sync.Mutex
+	paused     bool
+	running    int
+	everyStart time.Time
+	lastRun    *runRecord
+	stop       chan struct{} // Closed to stop the @every ticker.
+	expired    bool          // The expiry of the task was logged.
+	skipped    int           // Scheduled runs skipped because of calendars.
+}
+
+// setPaused pauses or resumes scheduled runs of the task.
+func (s *taskState) setPaused(paused bool) {
+	s.mu.Lock()
+	s.paused = paused
+	s.mu.Unlock()
+}
+
+// isPaused reports whether scheduled runs of the task are paused.
+func (s *taskState) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// setEveryStart records when the @every ticker of the task was started.
+func (s *taskState) setEveryStart(t time.Time) {
+	s.mu.Lock()
+	s.everyStart = t
+	s.mu.Unlock()
+}
+
+// nextEveryTick returns the first tick of an @every ticker after t.
+func (s *taskState) nextEveryTick(t time.Time, interval time.Duration) time.Time {
+	s.mu.Lock()
+	start := s.everyStart
+	s.mu.Unlock()
+
+	if start.IsZero() || interval <= 0 {
+		return time.Time{}
+	}
+	if t.Before(start) {
+		return start.Add(interval)
+	}
+	ticks := t.Sub(start)/interval + 1
+	return start.Add(ticks * interval)
+}
+
+// countSkipped counts a scheduled run skipped because of a calendar and
+// returns the number of such runs.
+func (s *taskState) countSkipped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped++
+	return s.skipped
+}
+
+// skippedRuns returns the number of scheduled runs skipped because of
+// calendars.
+func (s *taskState) skippedRuns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.skipped
+}
+
+// markExpired marks the task as expired and reports whether it was not
+// marked before.
+func (s *taskState) markExpired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := !s.expired
+	s.expired = true
+	return first
+}
+
+// startTicker returns a channel that is closed when the @every ticker of the
+// task must stop.
+func (s *taskState) startTicker() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop = make(chan struct{})
+	return s.stop
+}
+
+// stopTicker stops the @every ticker or @at timer of the task, if it is running.
+func (s *taskState) stopTicker() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// begin marks a run of the task as started.
+func (s *taskState) begin() {
+	s.mu.Lock()
+	s.running++
+	s.mu.Unlock()
+}
+
+// finish marks a run of the task as finished and records its outcome.
+func (s *taskState) finish(record runRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.lastRun = &record
+}
+
+// snapshot returns the running count and the last run since startup, if any.
+func (s *taskState) snapshot() (running int, lastRun *runRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running, s.lastRun
+}
+
+// scheduledDelay returns how long a scheduled run waits before it starts:
+// the start delay plus a random jitter below the maximum of the task.
+func (s *CronSchedule) scheduledDelay() time.Duration {
+	delay := s.startDelay
+	if s.jitter > 0 {
+		delay += rand.N(s.jitter)
+	}
+	return delay
+}
+
+// runTask executes a task and records the outcome in its state.
+// Scheduled, startup and catch-up runs are skipped while the task is paused;
+// manual runs are not. @at tasks run without start delay and jitter and are
+// marked completed when an automatic run starts.
+// Failed runs are retried up to the configured number of times; every attempt
+// is recorded in the history, but only the final one is notified.
+func runTask(task *CronSchedule, trigger string) {
+	if (trigger == triggerScheduled || trigger == triggerStartup || trigger == triggerCatchUp) && task.state.isPaused() {
+		log.Printf("Skipping paused task '%s'", task.name)
+		now := time.Now()
+		notifyRun(task, eventSkipped, runRecord{Task: task.name, Command: task.command, Trigger: trigger, Start: now, End: now})
+		return
+	}
+
+	if trigger != triggerManual && trigger != triggerRetry {
+		// Scheduled runs only happen between the bounds of the task.
+		now := time.Now()
+		if task.checkExpired(now) || !task.activeAt(now) {
+			return
+		}
+		if reason := task.calendarBlock(now); reason != "" {
+			count := task.state.countSkipped()
+			log.Printf("Skipping task '%s': %s (%d skipped so far)", task.name, reason, count)
+			return
+		}
+	}
+
+	if trigger == triggerScheduled && !task.isAt {
+		if delay := task.scheduledDelay(); delay > 0 {
+			log.Printf("Delaying task '%s' by %v", task.name, delay)
+			time.Sleep(delay)
+		}
+	}
+
+	if task.isAt && trigger != triggerManual {
+		// Completed when the run starts, so that it never runs twice, even
+		// if gron stops during the run.
+		if err := completions.markCompleted(task.name, task.at); err != nil {
+			log.Printf("Failed to record completion of task '%s': %v", task.name, err)
+		}
+	}
+
+	_, previous := task.state.snapshot()
+	if previous == nil {
+		previous = runHistory.last(task.name)
+	}
+
+	var pingDone chan<- runRecord
+	if task.ping != nil {
+		pingDone = task.ping.track(task.name)
+	}
+
+	task.state.begin()
+	var record runRecord
+	for attempt := 0; ; attempt++ {
+		record = executeCommandWith(task.name, task.command, task.exec)
+		record.ID = newRunID()
+		record.Task = task.name
+		record.Trigger = trigger
+		if attempt > 0 {
+			record.Trigger = triggerRetry
+		}
+		if record.ExitCode == 0 || attempt >= task.retries {
+			break
+		}
+
+		if err := runHistory.add(record); err != nil {
+			log.Printf("Failed to record run of task '%s': %v", task.name, err)
+		}
+		log.Printf("Task '%s' failed (attempt %d of %d), retrying in %v", task.name, attempt+1, task.retries+1, task.retryDelay)
+		time.Sleep(task.retryDelay)
+	}
+	if pingDone != nil {
+		pingDone <- record
+	}
+
+	if err := runHistory.add(record); err != nil {
+		log.Printf("Failed to record run of task '%s': %v", task.name, err)
+	}
+	task.state.finish(record)
+	notifyRun(task, runEvent(record, previous), record)
+}
+
+// exitCode extracts the exit code from a command error.
+// Returns -1 if the command could not be run at all.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// outputTail returns at most maxOutputTail trailing bytes of output.
+func outputTail(output []byte) string {
+	if len(output) > maxOutputTail {
+		output = output[len(output)-maxOutputTail:]
+	}
+	return string(output)
+}
+
+// taskRegistry keeps the tasks known to the running scheduler so that they
+// can be looked up by name.
+type taskRegistry struct {
+	mu    sync.RWMutex
+	tasks []*CronSchedule
+}
+
+// registry is the process-wide set of scheduled tasks.
+var registry = &taskRegistry{}
+
+// set replaces the registered tasks.
+func (r *taskRegistry) set(tasks []*CronSchedule) {
+	r.mu.Lock()
+	r.tasks = tasks
+	r.mu.Unlock()
+}
+
+// list returns the registered tasks sorted by name.
+func (r *taskRegistry) list() []*CronSchedule {
+	r.mu.RLock()
+	tasks := append([]*CronSchedule(nil), r.tasks...)
+	r.mu.RUnlock()
+
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].name < tasks[j].name })
+	return tasks
+}
+
+// get returns the task with the given name, or nil if there is none.
+func (r *taskRegistry) get(name string) *CronSchedule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, task := range r.tasks {
+		if task.name == name {
+			return task
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strictFromEnv reports whether gron refuses to start when a task fails to
+// load. Strict mode is on unless GRON_STRICT is set to false.
+func strictFromEnv() (bool, error) {
+	v := os.Getenv("GRON_STRICT")
+	if v == "" {
+		return true, nil
+	}
+	strict, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid GRON_STRICT '%s'", v)
+	}
+	return strict, nil
+}
+
+// normalized returns the schedule in canonical form: special formats are
+// expanded to the five cron fields, plus the year if one is given, lists are
+// sorted and merged into ranges and steps, @every intervals are written in
+// their shortest form and calendar events like systemd-analyze writes them.
+func (s *CronSchedule) normalized() string {
+	if s.isReboot {
+		return "@reboot"
+	}
+	if s.isAt {
+		return "@at " + s.at.Format(time.RFC3339)
+	}
+	if s.isOnCalendar {
+		return "@oncalendar " + s.normalizedOnCalendar()
+	}
+	if s.isEvery {
+		spec := "@every " + formatInterval(s.interval)
+		if s.aligned {
+			spec += " align"
+		}
+		if s.offset > 0 {
+			spec += " offset " + formatInterval(s.offset)
+		}
+		if !s.anchor.IsZero() {
+			spec += " anchor " + s.anchor.Format(time.RFC3339)
+		}
+		return spec
+	}
+
+	values := [][]int{s.minutes, s.hours, s.daysOfMonth, s.months, s.normalizedDaysOfWeek()}
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = formatField(v, cronFields[i])
+	}
+	if s.years != nil {
+		fields = append(fields, formatField(s.years, yearField))
+	}
+	return strings.Join(fields, " ")
+}
+
+// normalizedDaysOfWeek returns the sorted days of the week with Sunday
+// always written as 0.
+func (s *CronSchedule) normalizedDaysOfWeek() []int {
+	days := make([]int, 0, len(s.daysOfWeek))
+	seen := make(map[int]bool)
+	for _, d := range s.daysOfWeek {
+		d %= 7
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// fieldStep returns n if the sorted values of a field are exactly those of
+// "*/n", and 0 otherwise. The full range is "*/1".
+func fieldStep(values []int, limits CronField) int {
+	if len(values) < 2 || values[0] != limits.min {
+		return 0
+	}
+	step := values[1] - values[0]
+	for i := 1; i < len(values); i++ {
+		if values[i]-values[i-1] != step {
+			return 0
+		}
+	}
+	// The step must cover the whole range: "*/7" of minutes ends at 56.
+	if values[len(values)-1]+step <= limits.max {
+		return 0
+	}
+	return step
+}
+
+// formatField writes the sorted values of a cron field in canonical form:
+// "*" for the full range, "*/n" for a step from the minimum over the full
+// range, and otherwise a comma-separated list of values and ranges.
+func formatField(values []int, limits CronField) string {
+	if len(values) == 0 {
+		return "*"
+	}
+	switch step := fieldStep(values, limits); step {
+	case 0:
+	case 1:
+		return "*"
+	default:
+		return "*/" + strconv.Itoa(step)
+	}
+
+	var parts []string
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		switch {
+		case j == i:
+			parts = append(parts, strconv.Itoa(values[i]))
+		case j == i+1:
+			parts = append(parts, strconv.Itoa(values[i]), strconv.Itoa(values[j]))
+		default:
+			parts = append(parts, fmt.Sprintf("%d-%d", values[i], values[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatInterval writes a duration without zero units and with whole days,
+// e.g. "1h" rather than "1h0m0s" and "1d12h" rather than "36h0m0s".
+func formatInterval(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day {
+		days := fmt.Sprintf("%dd", d/day)
+		if d%day == 0 {
+			return days
+		}
+		return days + formatInterval(d%day)
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// runValidate runs the "validate" command with the process arguments and
+// standard streams.
+func runValidate(args []string) int {
+	return validateCommand(args, os.Environ(), os.Stdout, os.Stderr)
+}
+
+// validateCommand loads the configuration like gron would at startup and
+// prints every task with its normalized schedule, followed by the errors of
+// the tasks that failed to load. It exits with 1 if there are any.
+func validateCommand(args, environ []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", os.Getenv("GRON_CONFIG"), "path to a YAML or JSON configuration file")
+	var crontabs stringList
+	fs.Var(&crontabs, "f", "crontab file or cron.d directory to load (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(stderr, "Usage: gron validate [-config path] [-f crontab]...")
+		return 2
+	}
+
+	// Loading logs every task; only the result is of interest here.
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard)
+	config, err := loadConfiguration(*configPath, crontabs, environ)
+	log.SetOutput(logOutput)
+	if err != nil {
+		fmt.Fprintf(stderr, "validate: %v\n", err)
+		return 1
+	}
+
+	tw := newTable(stdout)
+	fmt.Fprintln(tw, "NAME\tSCHEDULE\tNORMALIZED\tDESCRIPTION\tCOMMAND")
+	for _, task := range config.tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", task.name, task.spec, task.normalized(), task.describe(), task.command)
+	}
+	tw.Flush()
+
+	for _, err := range config.errors {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+	}
+	if len(config.errors) > 0 {
+		fmt.Fprintf(stderr, "%d of %d tasks are invalid\n", len(config.errors), len(config.tasks)+len(config.errors))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestNormalized verifies the canonical form of schedules
+func TestNormalized(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"* * * * *", "* * * * *"},
+		{"@daily", "0 0 * * *"},
+		{"@weekly", "0 0 * * 0"},
+		{"0-59/15 * * * *", "*/15 * * * *"},
+		{"*/7 * * * *", "*/7 * * * *"},
+		{"0-30/10 9-17 * * 1-5", "0,10,20,30 9-17 * * 1-5"},
+		{"5,1,3,2 0 1,15 */3 7", "1-3,5 0 1,15 */3 0"},
+		{"0 12 * * 0,7", "0 12 * * 0"},
+		{"0 12 * * 5-7", "0 12 * * 0,5,6"},
+		{"@every 1h", "@every 1h"},
+		{"@every 90m", "@every 1h30m"},
+		{"@every 2d", "@every 2d"},
+		{"@every 36h", "@every 1d12h"},
+		{"@every 1.5d", "@every 1d12h"},
+		{"@every 1w", "@every 7d"},
+		{"@every 45s", "@every 45s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			task, err := parseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.normalized(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseScheduleErrors verifies errors point at the invalid field
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"61 * * * *", "field 1 (minute) '61': value 61 out of range 0-59"},
+		{"0 9-25 * * *", "field 2 (hour) '9-25': value 25 out of range 0-23"},
+		{"0 0 0 * *", "field 3 (day of month) '0': value 0 out of range 1-31"},
+		{"0 0 * 1,x *", "field 4 (month) '1,x': invalid value 'x'"},
+		{"0 0 * * 5-1", "field 5 (day of week) '5-1': invalid range 5-1"},
+		{"*/0 * * * *", "field 1 (minute) '*/0': step must be positive, got 0"},
+		{"*/x * * * *", "field 1 (minute) '*/x': invalid step 'x'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parseSchedule(tt.spec)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestValidateCommand verifies the output and exit code of gron validate
+func TestValidateCommand(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", "tasks:\n  report:\n    schedule: '0 9 * * 1-5'\n    command: report.sh\n  bad:\n    schedule: '0 25 * * *'\n    command: echo\n")
+	environ := []string{"TASK_POLL=@every 60m poll.sh"}
+
+	var stdout, stderr bytes.Buffer
+	if code := validateCommand([]string{"-config", path}, environ, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	out := stdout.String()
+	for _, want := range []string{"NORMALIZED", "poll", "@every 1h", "report", "0 9 * * 1-5"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if want := path + ":5: tasks.bad: field 2 (hour) '25': value 25 out of range 0-23"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("expected error %q, got:\n%s", want, stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := validateCommand(nil, environ, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+}
+
+// TestStrictFromEnv verifies strict mode is on by default
+func TestStrictFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+		wantErr  bool
+	}{
+		{"", true, false},
+		{"true", true, false},
+		{"false", false, false},
+		{"0", false, false},
+		{"maybe", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("GRON_STRICT", tt.value)
+			strict, err := strictFromEnv()
+			if (err != nil) != tt.wantErr || strict != tt.expected {
+				t.Errorf("expected %v (error %v), got %v, %v", tt.expected, tt.wantErr, strict, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxWindowSearch bounds how far ahead a window of time ranges is searched
+// for its next opening; such windows repeat every week.
+const maxWindowSearch = 8 * 24 * time.Hour
+
+// runWindow limits the scheduled runs of a task to certain times of day and
+// days of the week.
+type runWindow struct {
+	spec   string
+	ranges [][2]int      // Minutes of the day [start, end); overnight if start > end.
+	days   []int         // Days of the week; every day if nil.
+	cron   *CronSchedule // Cron-like constraint instead of ranges, if set.
+}
+
+// parseWindow parses a run window: time ranges with optional days, such as
+// "08:00-20:00 Mon-Fri" or "22:00-06:00", or a five-field cron-like
+// constraint on the minute of the run, such as "* 8-19 * * 1-5".
+func parseWindow(spec string) (*runWindow, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty window")
+	}
+	w := &runWindow{spec: strings.Join(fields, " ")}
+	if !strings.Contains(spec, ":") {
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("expected time ranges like 08:00-20:00 or five cron fields")
+		}
+		cron, err := parseCronSchedule(w.spec)
+		if err != nil {
+			return nil, err
+		}
+		w.cron = cron
+		return w, nil
+	}
+
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("expected time ranges optionally followed by days, like 08:00-20:00 Mon-Fri")
+	}
+	for _, part := range strings.Split(fields[0], ",") {
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time range '%s', expected hh:mm-hh:mm", part)
+		}
+		start, err := parseTimeOfDay(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeOfDay(to)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("empty time range '%s'", part)
+		}
+		w.ranges = append(w.ranges, [2]int{start, end})
+	}
+	if len(fields) == 2 {
+		days, err := parseWindowDays(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		w.days = days
+	}
+	return w, nil
+}
+
+// parseTimeOfDay parses "hh:mm" into minutes since midnight. "24:00" is the
+// end of the day.
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err == nil {
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	return 0, fmt.Errorf("invalid time of day '%s', expected hh:mm", value)
+}
+
+// parseWindowDays parses the days of a window: names or numbers of days of
+// the week, with ranges and lists, e.g. "Mon-Fri" or "sat,sun".
+func parseWindowDays(field string) ([]int, error) {
+	days, err := parseField(field, cronFields[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid days '%s': %v", field, err)
+	}
+	for i, d := range days {
+		days[i] = d % 7
+	}
+	return days, nil
+}
+
+// contains reports whether the window is open at t, in the location of t.
+func (w *runWindow) contains(t time.Time) bool {
+	if w.cron != nil {
+		return w.cron.shouldRun(t)
+	}
+	day := int(t.Weekday())
+	minute := t.Hour()*60 + t.Minute()
+	for _, r := range w.ranges {
+		start, end := r[0], r[1]
+		if (start < end && minute >= start && minute < end) ||
+			(start > end && (minute >= start || minute < end)) {
+			return w.days == nil || slices.Contains(w.days, day)
+		}
+	}
+	return false
+}
+
+// nextOpen returns the first time at or after t at which the window is
+// open, or the zero time if there is none.
+func (w *runWindow) nextOpen(t time.Time) time.Time {
+	if w.contains(t) {
+		return t
+	}
+	if w.cron != nil {
+		return w.cron.nextUnbounded(t)
+	}
+	limit := t.Add(maxWindowSearch)
+	for t = t.Truncate(time.Minute).Add(time.Minute); t.Before(limit); t = t.Add(time.Minute) {
+		if w.contains(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// inWindow reports whether t is within the run window of the task, in the
+// time zone of the task.
+func (s *CronSchedule) inWindow(t time.Time) bool {
+	if s.window == nil {
+		return true
+	}
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	return s.window.contains(t)
+}
+
+// nextWindowOpen returns the first time at or after t at which the run
+// window of the task is open, or the zero time if there is none.
+func (s *CronSchedule) nextWindowOpen(t time.Time) time.Time {
+	if s.window == nil {
+		return t
+	}
+	if s.location != nil {
+		t = t.In(s.location)
+	}
+	return s.window.nextOpen(t)
+}
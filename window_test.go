@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseWindow verifies run windows of time ranges and cron constraints
+func TestParseWindow(t *testing.T) {
+	// 2026-10-19 is a Monday, 2026-10-24 a Saturday.
+	tests := []struct {
+		spec   string
+		open   []string
+		closed []string
+	}{
+		{"08:00-20:00 Mon-Fri", []string{"2026-10-19T08:00:00Z", "2026-10-23T19:59:00Z"}, []string{"2026-10-19T07:59:00Z", "2026-10-19T20:00:00Z", "2026-10-24T12:00:00Z"}},
+		{"22:00-06:00", []string{"2026-10-19T23:00:00Z", "2026-10-20T05:59:00Z"}, []string{"2026-10-19T06:00:00Z", "2026-10-19T21:59:00Z"}},
+		{"09:00-12:00,13:00-17:00", []string{"2026-10-19T09:30:00Z", "2026-10-19T16:00:00Z"}, []string{"2026-10-19T12:30:00Z"}},
+		{"00:00-24:00 sat-sun", []string{"2026-10-24T00:00:00Z", "2026-10-25T23:59:00Z"}, []string{"2026-10-23T23:59:00Z"}},
+		{"* 8-19 * * 1-5", []string{"2026-10-19T08:00:00Z", "2026-10-19T19:59:00Z"}, []string{"2026-10-19T20:00:00Z", "2026-10-24T12:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			w, err := parseWindow(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, value := range tt.open {
+				at, _ := time.Parse(time.RFC3339, value)
+				if !w.contains(at) {
+					t.Errorf("expected the window to be open at %s", value)
+				}
+			}
+			for _, value := range tt.closed {
+				at, _ := time.Parse(time.RFC3339, value)
+				if w.contains(at) {
+					t.Errorf("expected the window to be closed at %s", value)
+				}
+			}
+		})
+	}
+}
+
+// TestParseWindowErrors verifies invalid run windows are rejected
+func TestParseWindowErrors(t *testing.T) {
+	tests := []struct {
+		spec        string
+		expectedErr string
+	}{
+		{"", "empty window"},
+		{"08:00", "invalid time range '08:00'"},
+		{"08:00-25:00", "invalid time of day '25:00'"},
+		{"08:00-08:00", "empty time range"},
+		{"08:00-20:00 Mon-Fri extra", "expected time ranges"},
+		{"08:00-20:00 Workdays", "invalid days 'Workdays'"},
+		{"* 8-19 * *", "five cron fields"},
+		{"* 8-25 * * *", "field 2 (hour)"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseWindow(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Errorf("%q: expected error containing '%s', got %v", tt.spec, tt.expectedErr, err)
+		}
+	}
+}
+
+// TestWindowSchedule verifies runs outside the window are left out without shifting the others
+func TestWindowSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	window, _ := parseWindow("08:00-20:00 Mon-Fri")
+
+	aligned, _ := parseSchedule("@every 45m align")
+	aligned.location = berlin
+	aligned.window = window
+	from, _ := time.Parse(time.RFC3339, "2026-10-23T19:00:00+02:00")
+	times := aligned.upcoming(from, 3)
+	expected := []string{"2026-10-23T19:30:00+02:00", "2026-10-26T08:15:00+01:00", "2026-10-26T09:00:00+01:00"}
+	if len(times) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, times)
+	}
+	for i, want := range expected {
+		if got := times[i].In(berlin).Format(time.RFC3339); got != want {
+			t.Errorf("aligned run %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	// Unaligned ticks keep counting from the start.
+	every, _ := parseSchedule("@every 25m")
+	every.location = time.UTC
+	every.window = window
+	from, _ = time.Parse(time.RFC3339, "2026-10-19T19:00:00Z")
+	times = every.upcoming(from, 3)
+	expected = []string{"2026-10-19T19:25:00Z", "2026-10-19T19:50:00Z", "2026-10-20T08:20:00Z"}
+	if len(times) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, times)
+	}
+	for i, want := range expected {
+		if got := times[i].Format(time.RFC3339); got != want {
+			t.Errorf("run %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	cron, _ := parseSchedule("0 * * * *")
+	cron.location = time.UTC
+	cron.window = window
+	if cron.shouldRun(time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)) {
+		t.Error("expected no run outside the window")
+	}
+	if !cron.shouldRun(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected a run inside the window")
+	}
+	if got := cron.next(time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC)).Format(time.RFC3339); got != "2026-10-26T08:00:00Z" {
+		t.Errorf("expected the next run on Monday morning, got %s", got)
+	}
+
+	never, _ := parseWindow("* * 31 2 *")
+	cron.window = never
+	if got := cron.next(from); !got.IsZero() {
+		t.Errorf("expected no run in a window that never opens, got %v", got)
+	}
+}
+
+// TestLoadConfigurationWindow verifies the window option
+func TestLoadConfigurationWindow(t *testing.T) {
+	path := writeConfig(t, "gron.yaml", `
+tasks:
+  poller:
+    schedule: "@every 5m"
+    command: poll.sh
+    window: 08:00-20:00 Mon-Fri
+  invalid:
+    schedule: "@every 5m"
+    command: poll.sh
+    window: mornings
+`)
+	environ := []string{"TASK_REPORT=0 * * * * report.sh", "TASK_REPORT_WINDOW=* 9-17 * * *"}
+	config, err := loadConfiguration(path, nil, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.errors) != 1 || !strings.Contains(config.errors[0].Error(), "tasks.invalid: invalid window 'mornings'") {
+		t.Errorf("unexpected errors: %v", config.errors)
+	}
+	for _, task := range config.tasks {
+		if task.window == nil {
+			t.Errorf("expected task %s to have a window", task.name)
+		}
+	}
+	if len(config.tasks) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(config.tasks))
+	}
+}